@@ -0,0 +1,15 @@
+package todo
+
+import "time"
+
+type Attachment struct {
+	Id          int       `json:"id" db:"id"`
+	ItemId      int       `json:"item_id" db:"item_id"`
+	UploaderId  int       `json:"uploader_id" db:"uploader_id"`
+	Filename    string    `json:"filename" db:"filename"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	Checksum    string    `json:"checksum" db:"checksum"` // sha256, hex
+	StorageKey  string    `json:"-" db:"storage_key"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
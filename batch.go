@@ -0,0 +1,108 @@
+package todo
+
+import "fmt"
+
+// Операции пакетного изменения задач.
+const (
+	ItemOpCreate = "create"
+	ItemOpUpdate = "update"
+	ItemOpDelete = "delete"
+	ItemOpMove   = "move"
+)
+
+// Режимы выполнения пакета: atomic откатывает все операции при первой ошибке,
+// best_effort выполняет каждую операцию независимо.
+const (
+	BatchModeAtomic     = "atomic"
+	BatchModeBestEffort = "best_effort"
+)
+
+const MaxBatchSize = 100
+
+// ItemOperation - одна операция пакета. Id нужен для update, delete и move,
+// ListId - для create и move (список, в который переносится задача).
+type ItemOperation struct {
+	Op      string           `json:"op"`
+	Id      int              `json:"id"`
+	ListId  int              `json:"list_id"`
+	Item    *TodoItem        `json:"item"`
+	Changes *UpdateItemInput `json:"changes"`
+	// Version - ожидаемая версия задачи; nil - без проверки
+	Version *int `json:"version"`
+}
+
+func (o ItemOperation) Validate() error {
+	switch o.Op {
+	case ItemOpCreate:
+		if o.ListId <= 0 {
+			return NewError(ErrValidation, "list_id is required")
+		}
+		if o.Item == nil || o.Item.Title == "" {
+			return NewError(ErrValidation, "item.title is required")
+		}
+		return o.Item.Validate()
+	case ItemOpUpdate:
+		if o.Id <= 0 {
+			return NewError(ErrValidation, "id is required")
+		}
+		if o.Changes == nil {
+			return NewError(ErrValidation, "changes are required")
+		}
+		return o.Changes.Validate()
+	case ItemOpDelete:
+		if o.Id <= 0 {
+			return NewError(ErrValidation, "id is required")
+		}
+	case ItemOpMove:
+		if o.Id <= 0 || o.ListId <= 0 {
+			return NewError(ErrValidation, "id and list_id are required")
+		}
+	default:
+		return NewError(ErrValidation, "unknown operation %q", o.Op)
+	}
+
+	return nil
+}
+
+type ItemBatchInput struct {
+	Mode       string          `json:"mode"` // по умолчанию atomic
+	Operations []ItemOperation `json:"operations"`
+}
+
+func (i ItemBatchInput) Atomic() bool {
+	return i.Mode != BatchModeBestEffort
+}
+
+func (i ItemBatchInput) Validate() error {
+	if i.Mode != "" && i.Mode != BatchModeAtomic && i.Mode != BatchModeBestEffort {
+		return NewError(ErrValidation, "mode must be %s or %s", BatchModeAtomic, BatchModeBestEffort)
+	}
+
+	if len(i.Operations) == 0 || len(i.Operations) > MaxBatchSize {
+		return NewError(ErrValidation, "batch must contain from 1 to %d operations", MaxBatchSize)
+	}
+
+	return nil
+}
+
+// ItemOperationResult - результат операции пакета. Id - id задачи, для create -
+// созданной; Err - ошибка операции, nil при успехе.
+type ItemOperationResult struct {
+	Id  int
+	Err error
+}
+
+// BatchOperationError - ошибка операции с номером Index, из-за которой
+// откатился атомарный пакет.
+type BatchOperationError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchOperationError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err.Error())
+}
+
+func (e *BatchOperationError) Unwrap() error {
+	return e.Err
+}
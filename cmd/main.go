@@ -1,22 +1,10 @@
 package main
 
 import (
-	"context"
-	"net/http"
-	"os"
-	"os/signal"
 	"strings"
-	"syscall"
 	"time"
 
-	todo "github.com/balamuteon/todo_restapi"
-	"github.com/balamuteon/todo_restapi/pkg/cache"
-	"github.com/balamuteon/todo_restapi/pkg/handler"
-	"github.com/balamuteon/todo_restapi/pkg/repository"
-	"github.com/balamuteon/todo_restapi/pkg/service"
-	"github.com/jmoiron/sqlx"
-
-	_ "github.com/lib/pq"
+	"github.com/balamuteon/todo_restapi/pkg/app"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -29,75 +17,13 @@ func main() {
 		logrus.Fatalf("error initializing configs: %s", err.Error())
 	}
 
-	// Добавляем цикл повторных попыток подключения к БД
-	var db *sqlx.DB
-	var err error
-	maxRetries := 5
-	retryDelay := time.Second * 5
-
-	for i := 0; i < maxRetries; i++ {
-		db, err = repository.NewPostgresDB(repository.Config{
-			Host:     viper.GetString("db.host"),
-			Port:     viper.GetString("db.port"),
-			Username: viper.GetString("db.username"),
-			DBName:   viper.GetString("db.dbname"),
-			SSLMode:  viper.GetString("db.sslmode"),
-			Password: viper.GetString("db.password"), // Viper теперь управляет и паролем
-		})
-
-		if err == nil {
-			logrus.Info("Successfully connected to the database.")
-			break // Успешно подключились, выходим из цикла
-		}
-
-		logrus.Warnf("Failed to connect to db, retrying in %v... (%d/%d)", retryDelay, i+1, maxRetries)
-		time.Sleep(retryDelay)
-	}
-
+	a, err := app.NewApp()
 	if err != nil {
-		logrus.Fatalf("failed to initialize db after %d retries: %s", maxRetries, err.Error())
+		logrus.Fatalf("failed to initialize app: %s", err.Error())
 	}
 
-	client, err := cache.NewRedisClient(&cache.Options{
-		Addr:     viper.GetString("redis.addr"),
-		Password: os.Getenv("REDIS_PASSWORD"),
-		DB:       viper.GetInt("redis.db"),
-	})
-	if err != nil {
-		logrus.Fatalf("failed to initialize cache client: %s", err.Error())
-	}
-
-	cache := cache.NewCache(client)
-	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
-	handlers := handler.NewHandler(services, cache)
-
-	srv := new(todo.Server)
-
-	go func() {
-		if err := srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil && err != http.ErrServerClosed {
-			logrus.Fatalf("error occured while running http server: %s", err.Error())
-		}
-	}()
-
-	logrus.Print("TodoApp Started")
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
-	<-quit
-
-	logrus.Print("TodoApp Shutting Down")
-
-	if err := srv.Shutdown(context.Background()); err != nil {
-		logrus.Errorf("error occured on server shutting down: %s", err.Error())
-	}
-
-	if err := client.Close(); err != nil {
-		logrus.Errorf("error occured on redis connection close: %s", err.Error())
-	}
-
-	if err := db.Close(); err != nil {
-		logrus.Errorf("error occured on db connection close: %s", err.Error())
+	if err := a.Run(); err != nil {
+		logrus.Errorf("error occured on app shutting down: %s", err.Error())
 	}
 }
 
@@ -112,9 +38,37 @@ func initConfig() error {
 	// Заменяем точки на подчеркивания для переменных (db.host -> DB_HOST)
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
+	viper.SetDefault("grpc.port", "50051")
+
 	viper.SetDefault("db.host", "db")
 	viper.SetDefault("db.port", "5432")
 	viper.SetDefault("db.sslmode", "disable")
 
+	viper.SetDefault("reminders.interval", 15*time.Second)
+	viper.SetDefault("reminders.batch_size", 50)
+	viper.SetDefault("reminders.lease", time.Minute)
+	viper.SetDefault("reminders.max_attempts", 5)
+	viper.SetDefault("reminders.backoff", 30*time.Second)
+	viper.SetDefault("reminders.max_backoff", time.Hour)
+	viper.SetDefault("reminders.webhook_timeout", 10*time.Second)
+
+	viper.SetDefault("email.interval", 15*time.Second)
+	viper.SetDefault("email.batch_size", 50)
+	viper.SetDefault("email.lease", time.Minute)
+	viper.SetDefault("email.max_attempts", 5)
+	viper.SetDefault("email.backoff", 30*time.Second)
+	viper.SetDefault("email.max_backoff", time.Hour)
+	viper.SetDefault("email.smtp.port", "587")
+
+	viper.SetDefault("trash.retention", 30*24*time.Hour)
+	viper.SetDefault("trash.purge_interval", time.Hour)
+	viper.SetDefault("undo.cleanup_interval", 10*time.Minute)
+	viper.SetDefault("search.config", "russian")
+
+	viper.SetDefault("storage.driver", "local")
+	viper.SetDefault("storage.local.dir", "./data/attachments")
+	viper.SetDefault("attachments.max_size", 10<<20)
+	viper.SetDefault("attachments.allowed_types", []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"})
+
 	return nil
 }
@@ -0,0 +1,33 @@
+package todo
+
+import (
+	"strings"
+	"time"
+)
+
+type Comment struct {
+	Id         int       `json:"id" db:"id"`
+	ItemId     int       `json:"item_id" db:"item_id"`
+	AuthorId   int       `json:"author_id" db:"author_id"`
+	AuthorName string    `json:"author_name" db:"author_name"`
+	Body       string    `json:"body" db:"body" binding:"required"`
+	Edited     bool      `json:"edited" db:"edited"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type UpdateCommentInput struct {
+	Body *string `json:"body"`
+}
+
+func (i UpdateCommentInput) Validate() error {
+	if i.Body == nil {
+		return NewError(ErrValidation, "update structure has no values")
+	}
+
+	if strings.TrimSpace(*i.Body) == "" {
+		return NewError(ErrValidation, "comment body is empty")
+	}
+
+	return nil
+}
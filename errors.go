@@ -0,0 +1,35 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Категории ошибок предметной области. Репозиторий и сервисы оборачивают их в
+// Error, а обработчики по errors.Is выбирают HTTP-статус.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrForbidden  = errors.New("forbidden")
+	ErrValidation = errors.New("validation failed")
+	ErrConflict   = errors.New("conflict")
+	// ErrPreconditionFailed - ресурс изменился с версии, которую ожидал клиент
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error - ошибка категории Kind с сообщением, которое можно показать клиенту.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func NewError(kind error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
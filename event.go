@@ -0,0 +1,83 @@
+package todo
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Типы событий об изменениях, которые рассылаются участникам списка.
+const (
+	EventListCreated = "list.created"
+	EventListUpdated = "list.updated"
+	EventListDeleted = "list.deleted"
+	EventItemCreated = "item.created"
+	EventItemUpdated = "item.updated"
+	// EventItemCompleted приходит вместе с item.updated, когда задача отмечена
+	// выполненной
+	EventItemCompleted = "item.completed"
+	EventItemDeleted   = "item.deleted"
+	EventItemMoved     = "item.moved"
+)
+
+// Event - событие об изменении списка или задачи. Само содержимое не
+// передается: клиент перечитывает запись по id. В потоке Id назначает брокер,
+// по нему клиент продолжает поток после переподключения; в вебхуках это номер
+// события в outbox, по нему отсеиваются повторы.
+type Event struct {
+	Id      string    `json:"id,omitempty"`
+	Type    string    `json:"type"`
+	ListId  int       `json:"list_id"`
+	ItemId  int       `json:"item_id,omitempty"`
+	ActorId int       `json:"actor_id"`
+	At      time.Time `json:"at"`
+}
+
+// OutboxEvent - событие, записанное в outbox в одной транзакции с изменением
+// и ждущее публикации. UserIds - получатели на момент изменения,
+// PublishedSinks - получатели событий, которые его уже приняли.
+type OutboxEvent struct {
+	Id             int64          `db:"id"`
+	Type           string         `db:"event_type"`
+	ListId         int            `db:"list_id"`
+	ItemId         int            `db:"item_id"`
+	ActorId        int            `db:"actor_id"`
+	UserIds        pq.Int64Array  `db:"user_ids"`
+	CreatedAt      time.Time      `db:"created_at"`
+	Attempts       int            `db:"attempts"`
+	PublishedSinks pq.StringArray `db:"published_sinks"`
+}
+
+// Event возвращает событие с id записи outbox: он не меняется при повторной
+// публикации, и по нему получатель отбрасывает повторы. Брокер потока событий
+// заменяет его своим.
+func (e OutboxEvent) Event() Event {
+	return Event{
+		Id:      strconv.FormatInt(e.Id, 10),
+		Type:    e.Type,
+		ListId:  e.ListId,
+		ItemId:  e.ItemId,
+		ActorId: e.ActorId,
+		At:      e.CreatedAt,
+	}
+}
+
+func (e OutboxEvent) PublishedTo(sink string) bool {
+	for _, published := range e.PublishedSinks {
+		if published == sink {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e OutboxEvent) Recipients() []int {
+	userIds := make([]int, len(e.UserIds))
+	for i, userId := range e.UserIds {
+		userIds[i] = int(userId)
+	}
+
+	return userIds
+}
@@ -0,0 +1,39 @@
+package todo
+
+import "time"
+
+const maxFilterQueryLength = 200
+
+// ItemFilter - условия выборки задач. Незаданные поля выборку не ограничивают.
+type ItemFilter struct {
+	Done      *bool      `form:"done"`
+	DueBefore *time.Time `form:"due_before"`
+	DueAfter  *time.Time `form:"due_after"`
+	Priority  *int       `form:"priority"`
+	Tag       string     `form:"tag"` // точное совпадение с одним из тегов задачи
+	Query     string     `form:"q"`
+}
+
+func (f ItemFilter) Validate() error {
+	if f.DueBefore != nil && f.DueAfter != nil && !f.DueAfter.Before(*f.DueBefore) {
+		return NewError(ErrValidation, "due_after must be earlier than due_before")
+	}
+
+	if f.Priority != nil {
+		if err := validatePriority(*f.Priority); err != nil {
+			return err
+		}
+	}
+
+	if f.Tag != "" {
+		if err := validateTag(f.Tag); err != nil {
+			return err
+		}
+	}
+
+	if len(f.Query) > maxFilterQueryLength {
+		return NewError(ErrValidation, "q must be at most %d characters", maxFilterQueryLength)
+	}
+
+	return nil
+}
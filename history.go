@@ -0,0 +1,53 @@
+package todo
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	HistoryEntityList = "list"
+	HistoryEntityItem = "item"
+)
+
+const (
+	HistoryActionCreate = "create"
+	HistoryActionUpdate = "update"
+	HistoryActionDelete = "delete"
+	// HistoryActionRestore - возврат из корзины или отмена удаления
+	HistoryActionRestore = "restore"
+)
+
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// FieldChanges - изменения по полям, хранятся в jsonb.
+type FieldChanges map[string]FieldChange
+
+func (c FieldChanges) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *FieldChanges) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.New("field changes must be scanned from []byte")
+	}
+
+	return json.Unmarshal(data, c)
+}
+
+type HistoryEntry struct {
+	Id         int          `json:"id" db:"id"`
+	EntityType string       `json:"entity_type" db:"entity_type"`
+	EntityId   int          `json:"entity_id" db:"entity_id"`
+	ActorId    *int         `json:"actor_id" db:"actor_id"`
+	ActorName  *string      `json:"actor_name" db:"actor_name"`
+	Action     string       `json:"action" db:"action"`
+	Changes    FieldChanges `json:"changes" db:"changes"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,69 @@
+package todo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+// PageInput - параметры постраничной выборки коллекций. Курсор непрозрачен для
+// клиента: внутри закодирован id последней отданной записи, поэтому выборка идет
+// по ключу (id > after) и новые записи не сдвигают уже отданные страницы.
+type PageInput struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+}
+
+type cursor struct {
+	AfterId int `json:"after_id"`
+}
+
+func (p PageInput) Validate() error {
+	if p.Limit < 0 || p.Limit > MaxPageLimit {
+		return NewError(ErrValidation, "limit must be between 1 and %d", MaxPageLimit)
+	}
+
+	if _, err := p.AfterId(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Size возвращает размер страницы с учетом значения по умолчанию.
+func (p PageInput) Size() int {
+	if p.Limit == 0 {
+		return DefaultPageLimit
+	}
+
+	return p.Limit
+}
+
+// AfterId возвращает id, после которого начинается страница; 0 - с начала.
+func (p PageInput) AfterId() (int, error) {
+	if p.Cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return 0, NewError(ErrValidation, "invalid cursor")
+	}
+
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.AfterId <= 0 {
+		return 0, NewError(ErrValidation, "invalid cursor")
+	}
+
+	return c.AfterId, nil
+}
+
+func EncodeCursor(afterId int) string {
+	raw, _ := json.Marshal(cursor{AfterId: afterId})
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
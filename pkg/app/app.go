@@ -4,27 +4,43 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	todo "github.com/balamuteon/todo_restapi"
 	"github.com/balamuteon/todo_restapi/pkg/cache"
+	"github.com/balamuteon/todo_restapi/pkg/events"
 	"github.com/balamuteon/todo_restapi/pkg/handler"
+	"github.com/balamuteon/todo_restapi/pkg/notify"
 	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/balamuteon/todo_restapi/pkg/rpc"
 	"github.com/balamuteon/todo_restapi/pkg/service"
+	"github.com/balamuteon/todo_restapi/pkg/storage"
+	"github.com/balamuteon/todo_restapi/pkg/worker"
 	"github.com/go-redis/redis/v8"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 )
 
+// grpcShutdownTimeout - сколько ждать завершения вызовов при остановке; потоки
+// событий сами не заканчиваются, поэтому после него сервер останавливается
+// принудительно.
+const grpcShutdownTimeout = 5 * time.Second
+
 type App struct {
 	db       *sqlx.DB
 	redis    *redis.Client
+	repos    *repository.Repository
+	store    storage.BlobStore
+	broker   events.Broker
 	services *service.Service
 	cache    cache.Cache
 }
@@ -46,13 +62,37 @@ func NewApp() (*App, error) {
 		return nil, fmt.Errorf("failed to initialize cache client: %w", err)
 	}
 
+	store, err := newBlobStore()
+	if err != nil {
+		client.Close()
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize blob store: %w", err)
+	}
+
+	searchConfig := viper.GetString("search.config")
+	if err := repository.CheckSearchConfig(db, searchConfig); err != nil {
+		client.Close()
+		db.Close()
+		return nil, err
+	}
+
 	appCache := cache.NewCache(client)
-	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
+	repos := repository.NewRepository(db, searchConfig)
+	broker := events.NewRedisBroker(client, events.RedisConfig{
+		ReplaySize: viper.GetInt64("events.replay_size"),
+		ReplayTTL:  viper.GetDuration("events.replay_ttl"),
+	})
+	services := service.NewService(repos, appCache, store, service.AttachmentLimits{
+		MaxSize:      viper.GetInt64("attachments.max_size"),
+		AllowedTypes: viper.GetStringSlice("attachments.allowed_types"),
+	}, broker)
 
 	return &App{
 		db:       db,
 		redis:    client,
+		repos:    repos,
+		store:    store,
+		broker:   broker,
 		services: services,
 		cache:    appCache,
 	}, nil
@@ -62,6 +102,10 @@ func NewApp() (*App, error) {
 func (a *App) Run() error {
 	handlers := handler.NewHandler(a.services, a.cache)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	a.startWorkers(ctx, &wg)
+
 	srv := new(todo.Server)
 	go func() {
 		if err := srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -69,7 +113,15 @@ func (a *App) Run() error {
 		}
 	}()
 
+	grpcServer := rpc.NewServer(a.services).InitServer()
+	go func() {
+		if err := runGRPC(grpcServer, viper.GetString("grpc.port")); err != nil {
+			logrus.Errorf("error occurred while running grpc server: %s", err.Error())
+		}
+	}()
+
 	logrus.Print("TodoApp Started on port: ", viper.GetString("port"))
+	logrus.Print("gRPC server started on port: ", viper.GetString("grpc.port"))
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
@@ -77,6 +129,11 @@ func (a *App) Run() error {
 
 	logrus.Print("TodoApp Shutting Down")
 
+	cancel()
+	wg.Wait()
+
+	stopGRPC(grpcServer, grpcShutdownTimeout)
+
 	if err := srv.Shutdown(context.Background()); err != nil {
 		return fmt.Errorf("error occurred on server shutting down: %w", err)
 	}
@@ -92,6 +149,131 @@ func (a *App) Run() error {
 	return nil
 }
 
+// startWorkers запускает фоновые обработчики, которые работают до отмены ctx.
+func (a *App) startWorkers(ctx context.Context, wg *sync.WaitGroup) {
+	notifiers := notify.Dispatcher{
+		todo.ReminderChannelWebhook: notify.NewWebhookNotifier(notify.NewPublicClient(viper.GetDuration("reminders.webhook_timeout"))),
+		todo.ReminderChannelLog:     notify.NewLogNotifier(),
+	}
+
+	runners := []interface{ Run(context.Context) }{}
+
+	// без SMTP-сервера письма некому отправить, поэтому канал email не
+	// подключается и такие напоминания завершаются ошибкой
+	if host := viper.GetString("email.smtp.host"); host != "" {
+		notifiers[todo.ReminderChannelEmail] = notify.NewEmailNotifier(a.repos.EmailOutbox)
+		runners = append(runners, worker.NewEmailSender(a.repos.EmailOutbox, notify.NewSMTPMailer(notify.SMTPConfig{
+			Host:     host,
+			Port:     viper.GetString("email.smtp.port"),
+			Username: viper.GetString("email.smtp.username"),
+			Password: viper.GetString("email.smtp.password"),
+			From:     viper.GetString("email.from"),
+		}), worker.EmailConfig{
+			Interval:    viper.GetDuration("email.interval"),
+			BatchSize:   viper.GetInt("email.batch_size"),
+			Lease:       viper.GetDuration("email.lease"),
+			MaxAttempts: viper.GetInt("email.max_attempts"),
+			Backoff:     viper.GetDuration("email.backoff"),
+			MaxBackoff:  viper.GetDuration("email.max_backoff"),
+		}))
+	} else {
+		logrus.Warn("email.smtp.host is not set, email reminders are disabled")
+	}
+
+	reminders := worker.NewReminderScheduler(a.repos.Reminder, notifiers, worker.ReminderConfig{
+		Interval:    viper.GetDuration("reminders.interval"),
+		BatchSize:   viper.GetInt("reminders.batch_size"),
+		Lease:       viper.GetDuration("reminders.lease"),
+		MaxAttempts: viper.GetInt("reminders.max_attempts"),
+		Backoff:     viper.GetDuration("reminders.backoff"),
+		MaxBackoff:  viper.GetDuration("reminders.max_backoff"),
+	})
+
+	purger := worker.NewTrashPurger(a.repos.Trash, a.store,
+		viper.GetDuration("trash.purge_interval"), viper.GetDuration("trash.retention"))
+
+	undoCleaner := worker.NewUndoCleaner(a.repos.Undo, viper.GetDuration("undo.cleanup_interval"))
+
+	webhooks := worker.NewWebhookDispatcher(a.repos.Webhook,
+		notify.NewWebhookSender(notify.NewPublicClient(viper.GetDuration("webhooks.timeout"))), worker.WebhookConfig{
+			Interval:     viper.GetDuration("webhooks.interval"),
+			BatchSize:    viper.GetInt("webhooks.batch_size"),
+			Lease:        viper.GetDuration("webhooks.lease"),
+			MaxAttempts:  viper.GetInt("webhooks.max_attempts"),
+			Backoff:      viper.GetDuration("webhooks.backoff"),
+			MaxBackoff:   viper.GetDuration("webhooks.max_backoff"),
+			DisableAfter: viper.GetInt("webhooks.disable_after"),
+		})
+
+	webhookLogCleaner := worker.NewWebhookLogCleaner(a.repos.Webhook,
+		viper.GetDuration("webhooks.cleanup_interval"), viper.GetDuration("webhooks.retention"))
+
+	// события из outbox уходят и подписчикам, и в очередь вебхуков
+	outbox := worker.NewOutboxRelay(a.repos.Outbox, worker.OutboxConfig{
+		Interval:   viper.GetDuration("outbox.interval"),
+		BatchSize:  viper.GetInt("outbox.batch_size"),
+		Lease:      viper.GetDuration("outbox.lease"),
+		Backoff:    viper.GetDuration("outbox.backoff"),
+		MaxBackoff: viper.GetDuration("outbox.max_backoff"),
+	},
+		worker.OutboxSink{Name: "events", Publisher: a.broker},
+		worker.OutboxSink{Name: "webhooks", Publisher: service.NewWebhookService(a.repos.Webhook)})
+
+	outboxCleaner := worker.NewOutboxCleaner(a.repos.Outbox,
+		viper.GetDuration("outbox.cleanup_interval"), viper.GetDuration("outbox.retention"))
+
+	runners = append(runners, reminders, purger, undoCleaner, webhooks, webhookLogCleaner, outbox, outboxCleaner)
+	for _, w := range runners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Run(ctx)
+		}()
+	}
+}
+
+func runGRPC(srv *grpc.Server, port string) error {
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+
+	return srv.Serve(listener)
+}
+
+// stopGRPC дает вызовам завершиться за timeout, а затем обрывает оставшиеся.
+func stopGRPC(srv *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		srv.Stop()
+	}
+}
+
+// newBlobStore создает хранилище вложений по storage.driver: local или s3.
+func newBlobStore() (storage.BlobStore, error) {
+	switch driver := viper.GetString("storage.driver"); driver {
+	case "local":
+		return storage.NewLocalStore(viper.GetString("storage.local.dir"))
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  viper.GetString("storage.s3.endpoint"),
+			Region:    viper.GetString("storage.s3.region"),
+			Bucket:    viper.GetString("storage.s3.bucket"),
+			AccessKey: viper.GetString("storage.s3.access_key"),
+			SecretKey: viper.GetString("storage.s3.secret_key"),
+		}, &http.Client{}), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
 // connectToDBWithRetry инкапсулирует логику подключения к БД с повторными попытками.
 func connectToDBWithRetry() (*sqlx.DB, error) {
 	var db *sqlx.DB
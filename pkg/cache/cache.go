@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -12,9 +13,22 @@ const (
 	CacheTTL = 15 * time.Minute
 )
 
+// UserListsKey и UserSmartListsKey - префиксы ключей выборок пользователя.
+// Ключи страниц и отдельных записей продолжают префикс, поэтому сбрасываются
+// все сразу по шаблону префикс*.
+func UserListsKey(userId int) string {
+	return fmt.Sprintf("user:%d:lists", userId)
+}
+
+func UserSmartListsKey(userId int) string {
+	return fmt.Sprintf("user:%d:smart:", userId)
+}
+
 type Cache interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value any, expiration time.Duration) error
+	// SetNX записывает значение, только если ключа еще нет, и сообщает, удалось ли
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error)
 	Delete(ctx context.Context, pattern string) error
 }
 
@@ -34,6 +48,14 @@ func (r *CacheClient) Set(ctx context.Context, key string, value any, expiration
 	return r.client.Set(ctx, key, bytes, expiration).Err()
 }
 
+func (r *CacheClient) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return r.client.SetNX(ctx, key, bytes, expiration).Result()
+}
+
 func (r *CacheClient) Delete(ctx context.Context, pattern string) error {
 	iter := r.client.Scan(ctx, 0, pattern, 0).Iterator()
 	for iter.Next(ctx) {
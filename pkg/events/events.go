@@ -0,0 +1,26 @@
+package events
+
+import (
+	"context"
+	"errors"
+
+	todo "github.com/balamuteon/todo_restapi"
+)
+
+// ErrInvalidEventId - Last-Event-ID не похож на id, выданный брокером.
+var ErrInvalidEventId = errors.New("invalid event id")
+
+// Publisher принимает события для рассылки пользователям userIds.
+type Publisher interface {
+	Publish(ctx context.Context, userIds []int, event todo.Event) error
+}
+
+// Broker доставляет события пользователям. Каждому пользователю события
+// приходят в порядке публикации; последние из них хранятся для продолжения
+// потока после переподключения.
+type Broker interface {
+	Publisher
+	// Subscribe отдает события пользователя до отмены ctx. Если lastEventId не
+	// пустой, сначала отдаются сохраненные события после него.
+	Subscribe(ctx context.Context, userId int, lastEventId string) (<-chan todo.Event, error)
+}
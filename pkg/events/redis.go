@@ -0,0 +1,209 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// subscriberBuffer - сколько событий ждет медленного подписчика, прежде чем
+// брокер начнет ждать его самого.
+const subscriberBuffer = 64
+
+type RedisConfig struct {
+	// ReplaySize - сколько последних событий пользователя хранится для
+	// Last-Event-ID, примерно
+	ReplaySize int64
+	// ReplayTTL - сколько хранится буфер пользователя без новых событий
+	ReplayTTL time.Duration
+}
+
+// RedisBroker рассылает события через Redis pub/sub, канал на пользователя.
+// Каждое событие дополнительно пишется в ограниченный stream пользователя, и
+// id записи в нем становится id события.
+type RedisBroker struct {
+	client *redis.Client
+	cfg    RedisConfig
+}
+
+func NewRedisBroker(client *redis.Client, cfg RedisConfig) *RedisBroker {
+	return &RedisBroker{client: client, cfg: cfg}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, userIds []int, event todo.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for _, userId := range userIds {
+		key := userKey(userId)
+		id, err := b.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: key,
+			MaxLen: b.cfg.ReplaySize,
+			Approx: true,
+			Values: map[string]interface{}{"event": data},
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		event.Id = id
+		message, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		_, err = b.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Expire(ctx, key, b.cfg.ReplayTTL)
+			pipe.Publish(ctx, key, message)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, userId int, lastEventId string) (<-chan todo.Event, error) {
+	var after streamId
+	if lastEventId != "" {
+		var ok bool
+		if after, ok = parseStreamId(lastEventId); !ok {
+			return nil, ErrInvalidEventId
+		}
+	}
+
+	// подписка оформляется до чтения буфера, чтобы не потерять события между ними
+	key := userKey(userId)
+	pubsub := b.client.Subscribe(ctx, key)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	var replay []todo.Event
+	if lastEventId != "" {
+		messages, err := b.client.XRange(ctx, key, lastEventId, "+").Result()
+		if err != nil {
+			pubsub.Close()
+			return nil, err
+		}
+
+		for _, message := range messages {
+			id, _ := parseStreamId(message.ID)
+			if !after.less(id) {
+				continue
+			}
+
+			event, err := decodeStreamMessage(message)
+			if err != nil {
+				logrus.Errorf("failed to decode event %s: %v", message.ID, err)
+				continue
+			}
+			replay = append(replay, event)
+			after = id
+		}
+	}
+
+	events := make(chan todo.Event, subscriberBuffer)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		for _, event := range replay {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var event todo.Event
+				if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+					logrus.Errorf("failed to decode event: %v", err)
+					continue
+				}
+				// событие уже могло прийти из буфера
+				if id, ok := parseStreamId(event.Id); ok && !after.less(id) {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func userKey(userId int) string {
+	return fmt.Sprintf("events:user:%d", userId)
+}
+
+func decodeStreamMessage(message redis.XMessage) (todo.Event, error) {
+	var event todo.Event
+	data, ok := message.Values["event"].(string)
+	if !ok {
+		return event, fmt.Errorf("stream entry has no event")
+	}
+
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return event, err
+	}
+	event.Id = message.ID
+
+	return event, nil
+}
+
+// streamId - id записи Redis stream: время в миллисекундах и номер внутри
+// миллисекунды.
+type streamId struct {
+	ms, seq uint64
+}
+
+func parseStreamId(id string) (streamId, bool) {
+	ms, seq, found := strings.Cut(id, "-")
+	if !found {
+		return streamId{}, false
+	}
+
+	msValue, err := strconv.ParseUint(ms, 10, 64)
+	if err != nil {
+		return streamId{}, false
+	}
+
+	seqValue, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return streamId{}, false
+	}
+
+	return streamId{ms: msValue, seq: seqValue}, true
+}
+
+func (id streamId) less(other streamId) bool {
+	return id.ms < other.ms || id.ms == other.ms && id.seq < other.seq
+}
@@ -0,0 +1,35 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStreamId(t *testing.T) {
+	id, ok := parseStreamId("1700000000000-2")
+	assert.True(t, ok, "expected valid id")
+	assert.Equal(t, streamId{ms: 1700000000000, seq: 2}, id, "unexpected id")
+
+	for _, invalid := range []string{"", "1700000000000", "abc-1", "1-", "-1", "1-2-3"} {
+		_, ok := parseStreamId(invalid)
+		assert.False(t, ok, "expected %q to be invalid", invalid)
+	}
+}
+
+func TestStreamIdLess(t *testing.T) {
+	tests := []struct {
+		a, b streamId
+		want bool
+	}{
+		{a: streamId{ms: 1, seq: 5}, b: streamId{ms: 2, seq: 0}, want: true},
+		{a: streamId{ms: 2, seq: 0}, b: streamId{ms: 2, seq: 1}, want: true},
+		{a: streamId{ms: 2, seq: 1}, b: streamId{ms: 2, seq: 1}, want: false},
+		{a: streamId{ms: 3, seq: 0}, b: streamId{ms: 2, seq: 9}, want: false},
+		{a: streamId{}, b: streamId{ms: 0, seq: 1}, want: true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.a.less(tt.b), "%v < %v", tt.a, tt.b)
+	}
+}
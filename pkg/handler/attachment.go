@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	attachmentFormField = "file"
+	// multipartOverhead - запас на заголовки частей и поля формы сверх самого файла
+	multipartOverhead = 64 << 10
+)
+
+func (h *Handler) uploadAttachment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	// тело ограничивается до разбора формы: иначе FormFile целиком сохранит
+	// сколь угодно большой файл во временный каталог
+	maxSize := h.services.Attachment.MaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	header, err := c.FormFile(attachmentFormField)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		newErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("attachment exceeds max size of %d bytes", maxSize))
+		return
+	}
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.services.Attachment.Upload(c.Request.Context(), userId, itemId, header.Filename, header.Size, file)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+func (h *Handler) getAllAttachments(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	attachments, err := h.services.Attachment.GetAll(userId, itemId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+func (h *Handler) downloadAttachment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	attachmentId, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid attachment id param")
+		return
+	}
+
+	attachment, obj, err := h.services.Attachment.Open(c.Request.Context(), userId, itemId, attachmentId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	defer func() {
+		if err := obj.Close(); err != nil {
+			logrus.Errorf("failed to close attachment %d: %s", attachment.Id, err.Error())
+		}
+	}()
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	c.Header("ETag", strconv.Quote(attachment.Checksum))
+
+	// ServeContent сам обрабатывает Range, If-Range и If-None-Match
+	http.ServeContent(c.Writer, c.Request, attachment.Filename, attachment.CreatedAt, obj)
+}
+
+func (h *Handler) deleteAttachment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	attachmentId, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid attachment id param")
+		return
+	}
+
+	if err := h.services.Attachment.Delete(c.Request.Context(), userId, itemId, attachmentId); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{Status: "ok"})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAttachments запоминает, сколько байт файла дошло до сервиса.
+type fakeAttachments struct {
+	service.Attachment
+	maxSize  int64
+	uploaded int
+}
+
+func (f *fakeAttachments) MaxSize() int64 {
+	return f.maxSize
+}
+
+func (f *fakeAttachments) Upload(ctx context.Context, userId, itemId int, filename string, size int64, content io.Reader) (todo.Attachment, error) {
+	data, err := io.ReadAll(content)
+	f.uploaded = len(data)
+
+	return todo.Attachment{Id: 1, ItemId: itemId, Filename: filename, Size: size}, err
+}
+
+func TestUploadAttachment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	upload := func(attachments *fakeAttachments, size int) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile(attachmentFormField, "notes.txt")
+		part.Write(bytes.Repeat([]byte("a"), size))
+		form.Close()
+
+		h := &Handler{services: &service.Service{Attachment: attachments}}
+		router := gin.New()
+		router.POST("/items/:id/attachments", func(c *gin.Context) { c.Set(userCtx, 1) }, h.uploadAttachment)
+
+		req := httptest.NewRequest(http.MethodPost, "/items/1/attachments", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	t.Run("uploads file within limit", func(t *testing.T) {
+		attachments := &fakeAttachments{maxSize: 1 << 10}
+		w := upload(attachments, 512)
+
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status")
+		assert.Equal(t, 512, attachments.uploaded, "expected file to reach service")
+	})
+
+	t.Run("rejects body over limit before parsing form", func(t *testing.T) {
+		attachments := &fakeAttachments{maxSize: 1 << 10}
+		w := upload(attachments, multipartOverhead+4<<10)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "unexpected status")
+		assert.Equal(t, 0, attachments.uploaded, "expected service not to be called")
+	})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	todo "github.com/balamuteon/todo_restapi"
@@ -10,14 +11,13 @@ import (
 func (h *Handler) signUp(c *gin.Context) {
 	var input todo.User
 
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	if err := bindJSON(c, &input); err != nil {
 		return
 	}
 
 	id, err := h.services.Authorization.CreateUser(input)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newServiceErrorResponse(c, err)
 		return
 	}
 
@@ -34,14 +34,17 @@ type signInInput struct {
 func (h *Handler) signIn(c *gin.Context) {
 	var input signInInput
 
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	if err := bindJSON(c, &input); err != nil {
 		return
 	}
 
 	token, err := h.services.Authorization.GenerateToken(input.Username, input.Password)
+	if errors.Is(err, todo.ErrNotFound) {
+		newErrorResponse(c, http.StatusUnauthorized, "invalid username or password")
+		return
+	}
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newServiceErrorResponse(c, err)
 		return
 	}
 
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+)
+
+type batchResponse struct {
+	Results   []batchResult `json:"results"`
+	UndoToken string        `json:"undo_token,omitempty"`
+}
+
+// batchResult - итог одной операции пакета; Error заполняется только при ошибке.
+type batchResult struct {
+	Index  int      `json:"index"`
+	Op     string   `json:"op"`
+	Id     int      `json:"id,omitempty"`
+	Status int      `json:"status"`
+	Error  *problem `json:"error,omitempty"`
+}
+
+// batchItems выполняет пакет операций над задачами. Атомарный пакет с ошибкой
+// целиком отвечает ошибкой операции; в режиме best_effort ответ всегда 200, а
+// ошибки перечислены по операциям. Кэш сбрасывается один раз на весь пакет.
+func (h *Handler) batchItems(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	var input todo.ItemBatchInput
+	if err := bindJSON(c, &input); err != nil {
+		return
+	}
+
+	results, undoToken, err := h.services.TodoItem.Batch(userId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	response := batchResponse{Results: make([]batchResult, len(results)), UndoToken: undoToken}
+	for i, result := range results {
+		response.Results[i] = batchOperationResult(c, i, input.Operations[i], result)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func batchOperationResult(c *gin.Context, index int, op todo.ItemOperation, result todo.ItemOperationResult) batchResult {
+	if result.Err != nil {
+		p := completeProblem(c, serviceProblem(result.Err), result.Err.Error())
+		return batchResult{Index: index, Op: op.Op, Id: result.Id, Status: p.Status, Error: &p}
+	}
+
+	status := http.StatusOK
+	if op.Op == todo.ItemOpCreate {
+		status = http.StatusCreated
+	}
+
+	return batchResult{Index: index, Op: op.Op, Id: result.Id, Status: status}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBatch запоминает последний пакет.
+type fakeBatch struct {
+	service.TodoItem
+	input todo.ItemBatchInput
+}
+
+func (f *fakeBatch) Batch(userId int, input todo.ItemBatchInput) ([]todo.ItemOperationResult, string, error) {
+	f.input = input
+
+	results := make([]todo.ItemOperationResult, len(input.Operations))
+	for i, op := range input.Operations {
+		results[i] = todo.ItemOperationResult{Id: op.Id}
+	}
+
+	return results, "", nil
+}
+
+func TestBatchItems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	items := &fakeBatch{}
+	h := &Handler{services: &service.Service{TodoItem: items}}
+	router := gin.New()
+	router.POST("/items/batch", func(c *gin.Context) { c.Set(userCtx, 1) }, h.batchItems)
+
+	body := `{"operations": [{"op": "update", "id": 1, "changes": {"clear_due_at": true}}]}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/batch", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, w.Code, "expected 200")
+	if assert.Len(t, items.input.Operations, 1, "expected one operation") {
+		changes := items.input.Operations[0].Changes
+		assert.True(t, changes.ClearDueAt, "expected due_at to be cleared")
+		assert.NoError(t, changes.Validate(), "expected clear flag alone to be a valid change")
+	}
+}
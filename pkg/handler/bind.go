@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// в ошибках валидации поля называются так же, как в JSON
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// bindJSON читает тело запроса в obj; при ошибке ответ уже отправлен.
+func bindJSON(c *gin.Context, obj interface{}) error {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		newBindErrorResponse(c, err)
+		return err
+	}
+
+	return nil
+}
+
+// newBindErrorResponse отправляет ошибку разбора тела запроса. Ошибки
+// валидации и несовпадения типов перечисляются по полям.
+func newBindErrorResponse(c *gin.Context, err error) {
+	var (
+		validationErrs validator.ValidationErrors
+		typeErr        *json.UnmarshalTypeError
+		syntaxErr      *json.SyntaxError
+	)
+
+	p := problem{Status: http.StatusBadRequest, Code: codeValidation}
+	switch {
+	case errors.As(err, &validationErrs):
+		p.Detail = "request body failed validation"
+		for _, fe := range validationErrs {
+			p.Errors = append(p.Errors, fieldError{Field: fe.Field(), Message: validationMessage(fe)})
+		}
+	case errors.As(err, &typeErr):
+		p.Detail = "request body failed validation"
+		p.Errors = []fieldError{{Field: typeErr.Field, Message: fmt.Sprintf("must be of type %s", typeErr.Type)}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		p.Code = codeBadRequest
+		p.Detail = "malformed JSON body"
+	case errors.Is(err, io.EOF):
+		p.Code = codeBadRequest
+		p.Detail = "empty request body"
+	default:
+		p.Code = codeBadRequest
+		p.Detail = err.Error()
+	}
+
+	abortWithProblem(c, p, err.Error())
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	default:
+		return fmt.Sprintf("failed the %q rule", fe.Tag())
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) createComment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	var input todo.Comment
+	if err := bindJSON(c, &input); err != nil {
+		return
+	}
+
+	id, err := h.services.Comment.Create(userId, itemId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"id": id,
+	})
+}
+
+func (h *Handler) getAllComments(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	comments, err := h.services.Comment.GetAll(userId, itemId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+func (h *Handler) updateComment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	commentId, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid comment id param")
+		return
+	}
+
+	var input todo.UpdateCommentInput
+	if err := bindJSON(c, &input); err != nil {
+		return
+	}
+
+	if err := h.services.Comment.Update(userId, itemId, commentId, input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+func (h *Handler) deleteComment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	commentId, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid comment id param")
+		return
+	}
+
+	if err := h.services.Comment.Delete(userId, itemId, commentId); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{Status: "ok"})
+}
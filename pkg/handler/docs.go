@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec описывает все маршруты InitRoutes; TestOpenAPISpec_MatchesRoutes
+// падает, если спецификация и маршруты расходятся.
+//
+//go:embed docs/openapi.json
+var openAPISpec []byte
+
+//go:embed docs/index.html
+var docsPage []byte
+
+// swaggerUI - сборка swagger-ui, которую загружает docsPage. Она отдается
+// самим сервисом, чтобы документация не зависела от внешних CDN.
+//
+//go:embed docs/swagger-ui/swagger-ui.css docs/swagger-ui/swagger-ui-bundle.js
+var swaggerUI embed.FS
+
+func (h *Handler) getOpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+}
+
+func (h *Handler) getDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
+
+func docsAssets() http.FileSystem {
+	assets, err := fs.Sub(swaggerUI, "docs/swagger-ui")
+	if err != nil {
+		panic(err)
+	}
+
+	return http.FS(assets)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/balamuteon/todo_restapi/pkg/service"
+	"github.com/stretchr/testify/assert"
+)
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPISpec_MatchesRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	assert.NoError(t, json.Unmarshal(openAPISpec, &spec), "expected valid spec")
+
+	documented := make([]string, 0)
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	h := NewHandler(&service.Service{}, nil)
+	registered := make([]string, 0)
+	for _, route := range h.InitRoutes().Routes() {
+		// сама документация в спецификацию не входит
+		if route.Path == "/openapi.json" || strings.HasPrefix(route.Path, "/docs") {
+			continue
+		}
+		registered = append(registered, route.Method+" "+pathParam.ReplaceAllString(route.Path, "{$1}"))
+	}
+
+	assert.ElementsMatch(t, registered, documented, "routes and openapi spec diverge")
+}
+
+func TestDocs_ServesSwaggerUI(t *testing.T) {
+	router := NewHandler(&service.Service{}, nil).InitRoutes()
+
+	for _, path := range []string{"/docs/assets/swagger-ui.css", "/docs/assets/swagger-ui-bundle.js"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.NotEmpty(t, w.Body.Bytes(), path)
+		assert.Contains(t, string(docsPage), path)
+	}
+	assert.NotContains(t, string(docsPage), "unpkg.com")
+}
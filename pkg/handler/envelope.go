@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+)
+
+// В /api/v2 все успешные ответы имеют одинаковый вид: ресурс или коллекция в
+// data, служебные поля в meta.
+
+// resourceResponse - один ресурс. Meta есть только у изменений, которые можно
+// отменить.
+type resourceResponse[T any] struct {
+	Data T             `json:"data"`
+	Meta *resourceMeta `json:"meta,omitempty"`
+}
+
+type resourceMeta struct {
+	UndoToken string `json:"undo_token,omitempty"`
+}
+
+// pageResponse - страница коллекции с параметрами пагинации.
+type pageResponse[T any] struct {
+	Data []T      `json:"data"`
+	Meta pageMeta `json:"meta"`
+}
+
+type pageMeta struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// collectionResponse - коллекция без пагинации.
+type collectionResponse[T any] struct {
+	Data []T `json:"data"`
+}
+
+func newPageResponse[T any](data []T, page todo.PageInput, nextCursor string) pageResponse[T] {
+	return pageResponse[T]{
+		Data: data,
+		Meta: pageMeta{
+			Limit:      page.Size(),
+			NextCursor: nextCursor,
+			HasMore:    nextCursor != "",
+		},
+	}
+}
+
+func undoMeta(undoToken string) *resourceMeta {
+	if undoToken == "" {
+		return nil
+	}
+
+	return &resourceMeta{UndoToken: undoToken}
+}
+
+// created отвечает 201 с адресом созданного ресурса в Location.
+func created[T any](c *gin.Context, location string, id int, data T) {
+	c.Header("Location", fmt.Sprintf(location, id))
+	c.JSON(http.StatusCreated, resourceResponse[T]{Data: data})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ifMatchHeader     = "If-Match"
+	ifNoneMatchHeader = "If-None-Match"
+)
+
+// etag - сильный ETag ресурса по его версии.
+func etag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// notModified выставляет ETag ресурса и отвечает 304, если клиент прислал его
+// же в If-None-Match. Сравнение слабое: префикс W/ не учитывается.
+func notModified(c *gin.Context, version int) bool {
+	tag := etag(version)
+	c.Header("ETag", tag)
+
+	header := c.GetHeader(ifNoneMatchHeader)
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
+
+// getIfMatch читает ожидаемую версию из If-Match. Без заголовка или со
+// значением * возвращает nil - версия не проверяется. Поддерживается один
+// ETag; при ошибке ответ уже отправлен.
+func getIfMatch(c *gin.Context) (*int, error) {
+	header := strings.TrimSpace(c.GetHeader(ifMatchHeader))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	// слабые ETag в If-Match не совпадают ни с чем (RFC 9110, 13.1.1)
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 3 {
+		newErrorResponse(c, http.StatusBadRequest, "If-Match must contain a single entity tag")
+		return nil, errors.New("invalid If-Match header")
+	}
+
+	return &version, nil
+}
+
+// requireIfMatch - getIfMatch для /api/v2, где условный запрос обязателен:
+// без If-Match отвечает 428.
+func requireIfMatch(c *gin.Context) (*int, error) {
+	if c.GetHeader(ifMatchHeader) == "" {
+		newErrorResponse(c, http.StatusPreconditionRequired, "If-Match header is required")
+		return nil, errors.New("missing If-Match header")
+	}
+
+	return getIfMatch(c)
+}
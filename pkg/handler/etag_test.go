@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveConditional(handler gin.HandlerFunc, headers map[string]string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{name: "no header", status: http.StatusOK},
+		{name: "same version", ifNoneMatch: `"3"`, status: http.StatusNotModified},
+		{name: "weak tag in list", ifNoneMatch: `"1", W/"3"`, status: http.StatusNotModified},
+		{name: "any", ifNoneMatch: "*", status: http.StatusNotModified},
+		{name: "other version", ifNoneMatch: `"2"`, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveConditional(func(c *gin.Context) {
+				if notModified(c, 3) {
+					return
+				}
+				c.Status(http.StatusOK)
+			}, map[string]string{ifNoneMatchHeader: tt.ifNoneMatch})
+
+			assert.Equal(t, tt.status, w.Code, "unexpected status")
+			assert.Equal(t, `"3"`, w.Header().Get("ETag"), "expected ETag header")
+		})
+	}
+}
+
+func TestGetIfMatch(t *testing.T) {
+	three := 3
+	tests := []struct {
+		name     string
+		ifMatch  string
+		required bool
+		status   int
+		version  *int
+	}{
+		{name: "no header", status: http.StatusOK},
+		{name: "any", ifMatch: "*", status: http.StatusOK},
+		{name: "strong tag", ifMatch: `"3"`, status: http.StatusOK, version: &three},
+		{name: "weak tag", ifMatch: `W/"3"`, status: http.StatusBadRequest},
+		{name: "unquoted", ifMatch: "3", status: http.StatusBadRequest},
+		{name: "several tags", ifMatch: `"3", "4"`, status: http.StatusBadRequest},
+		{name: "required and missing", required: true, status: http.StatusPreconditionRequired},
+		{name: "required and present", ifMatch: `"3"`, required: true, status: http.StatusOK, version: &three},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var version *int
+			w := serveConditional(func(c *gin.Context) {
+				read := getIfMatch
+				if tt.required {
+					read = requireIfMatch
+				}
+
+				var err error
+				if version, err = read(c); err != nil {
+					return
+				}
+				c.Status(http.StatusOK)
+			}, map[string]string{ifMatchHeader: tt.ifMatch})
+
+			assert.Equal(t, tt.status, w.Code, "unexpected status")
+			assert.Equal(t, tt.version, version, "unexpected version")
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	lastEventIdHeader = "Last-Event-ID"
+	// eventsHeartbeat не дает прокси закрыть поток, в котором давно не было событий
+	eventsHeartbeat = 25 * time.Second
+)
+
+// tokenFromQuery переносит токен из параметра access_token в заголовок
+// Authorization: EventSource и WebSocket в браузере не умеют передавать заголовки.
+func tokenFromQuery(c *gin.Context) {
+	if token := c.Query("access_token"); token != "" && c.GetHeader(authorizationHeader) == "" {
+		c.Request.Header.Set(authorizationHeader, "Bearer "+token)
+	}
+}
+
+// lastEventId берет id последнего полученного события из заголовка, который
+// EventSource присылает при переподключении, или из параметра last_event_id.
+func lastEventId(c *gin.Context) string {
+	if id := c.GetHeader(lastEventIdHeader); id != "" {
+		return id
+	}
+
+	return c.Query("last_event_id")
+}
+
+// streamEvents отдает события списков пользователя как Server-Sent Events.
+func (h *Handler) streamEvents(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	events, err := h.services.Events.Subscribe(ctx, userId, lastEventId(c))
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	// поток живет дольше общего WriteTimeout сервера
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logrus.Debugf("failed to reset write deadline: %v", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	// отключение клиента видно по отмене контекста запроса
+	for {
+		var err error
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			err = writeSSE(c.Writer, event)
+		case <-heartbeat.C:
+			_, err = io.WriteString(c.Writer, ": ping\n\n")
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeSSE(w io.Writer, event todo.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+	return err
+}
+
+// eventsSocket отдает те же события через WebSocket, по одному JSON-сообщению
+// на событие. Сообщения клиента не обрабатываются.
+func (h *Handler) eventsSocket(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	events, err := h.services.Events.Subscribe(ctx, userId, lastEventId(c))
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	// Origin не проверяется: доступ дает токен, а не cookie, и чужая страница
+	// без токена ничего не получит
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer cancel()
+		// сервер выставил соединению общие таймауты, поток живет дольше
+		ws.SetDeadline(time.Time{})
+
+		// чтение нужно, чтобы заметить закрытие соединения клиентом
+		go func() {
+			io.Copy(io.Discard, ws)
+			cancel()
+		}()
+
+		heartbeat := time.NewTicker(eventsHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := websocket.JSON.Send(ws, event); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if err := pingSocket(ws); err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// pingSocket отправляет ping-фрейм; ответный pong библиотека пропускает сама.
+func pingSocket(ws *websocket.Conn) error {
+	ws.PayloadType = websocket.PingFrame
+	defer func() { ws.PayloadType = websocket.TextFrame }()
+
+	_, err := ws.Write(nil)
+	return err
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEvents отдает заранее заданные события и закрывает поток.
+type fakeEvents struct {
+	events      []todo.Event
+	err         error
+	lastEventId string
+}
+
+func (f *fakeEvents) Subscribe(ctx context.Context, userId int, lastEventId string) (<-chan todo.Event, error) {
+	f.lastEventId = lastEventId
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	ch := make(chan todo.Event, len(f.events))
+	for _, event := range f.events {
+		ch <- event
+	}
+	close(ch)
+
+	return ch, nil
+}
+
+func TestStreamEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	at := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("streams events and passes Last-Event-ID", func(t *testing.T) {
+		events := &fakeEvents{events: []todo.Event{
+			{Id: "1700000000000-1", Type: todo.EventItemUpdated, ListId: 1, ItemId: 2, ActorId: 3, At: at},
+		}}
+		h := &Handler{services: &service.Service{Events: events}}
+		router := gin.New()
+		router.GET("/events", func(c *gin.Context) { c.Set(userCtx, 1) }, h.streamEvents)
+
+		req := httptest.NewRequest(http.MethodGet, "/events", nil)
+		req.Header.Set(lastEventIdHeader, "1700000000000-0")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "unexpected status")
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"), "unexpected content type")
+		assert.Equal(t, "1700000000000-0", events.lastEventId, "expected Last-Event-ID to be passed")
+		assert.Equal(t, "id: 1700000000000-1\nevent: item.updated\n"+
+			`data: {"id":"1700000000000-1","type":"item.updated","list_id":1,"item_id":2,"actor_id":3,"at":"2024-05-10T12:00:00Z"}`+"\n\n",
+			w.Body.String(), "unexpected stream")
+	})
+
+	t.Run("invalid Last-Event-ID", func(t *testing.T) {
+		events := &fakeEvents{err: todo.NewError(todo.ErrValidation, "invalid Last-Event-ID")}
+		h := &Handler{services: &service.Service{Events: events}}
+		router := gin.New()
+		router.GET("/events", func(c *gin.Context) { c.Set(userCtx, 1) }, h.streamEvents)
+
+		req := httptest.NewRequest(http.MethodGet, "/events?last_event_id=abc", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status")
+		assert.Equal(t, "abc", events.lastEventId, "expected query parameter to be used")
+	})
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"net/http"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+)
+
+// getItemFilter читает параметры done, due_before, due_after и q; даты в RFC 3339.
+// При ошибке ответ уже отправлен.
+func getItemFilter(c *gin.Context) (todo.ItemFilter, error) {
+	var filter todo.ItemFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid filter params: "+err.Error())
+		return filter, err
+	}
+
+	if err := filter.Validate(); err != nil {
+		newServiceErrorResponse(c, err)
+		return filter, err
+	}
+
+	return filter, nil
+}
@@ -20,6 +20,11 @@ func NewHandler(services *service.Service, cache cache.Cache) *Handler {
 
 func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
+	router.Use(requestId)
+
+	router.GET("/openapi.json", h.getOpenAPISpec)
+	router.GET("/docs", h.getDocs)
+	router.StaticFS("/docs/assets", docsAssets())
 
 	auth := router.Group("/auth")
 	{
@@ -31,25 +36,134 @@ func (h *Handler) InitRoutes() *gin.Engine {
 	{
 		lists := api.Group("/lists")
 		{
-			lists.POST("/", h.createList)
+			lists.POST("/", h.idempotent, h.createList)
 			lists.GET("/", h.getAllLists)
 			lists.GET("/:id", h.getListById)
 			lists.PUT("/:id", h.updateList)
+			lists.PATCH("/:id", h.patchList)
 			lists.DELETE("/:id", h.deleteList)
+			lists.GET("/:id/history", h.getListHistory)
 
 			items := lists.Group(":id/items")
 			{
-				items.POST("/", h.createItem)
+				items.POST("/", h.idempotent, h.createItem)
 				items.GET("/", h.getAllItems)
 			}
 		}
 
 		items := api.Group("items")
 		{
+			items.GET("/", h.getAllItemsForUser)
+			items.POST("/batch", h.batchItems)
 			items.GET("/:id", h.getItemById)
 			items.PUT("/:id", h.updateItem)
+			items.PATCH("/:id", h.patchItem)
 			items.DELETE("/:id", h.deleteItem)
+			items.PUT("/:id/assignee", h.assignItem)
+			items.GET("/:id/history", h.getItemHistory)
+
+			reminders := items.Group(":id/reminders")
+			{
+				reminders.POST("/", h.idempotent, h.createReminder)
+				reminders.GET("/", h.getAllReminders)
+			}
+
+			comments := items.Group(":id/comments")
+			{
+				comments.POST("/", h.idempotent, h.createComment)
+				comments.GET("/", h.getAllComments)
+				comments.PUT("/:comment_id", h.updateComment)
+				comments.DELETE("/:comment_id", h.deleteComment)
+			}
+
+			// загрузка без idempotent: ради отпечатка запроса файл до
+			// attachments.max_size пришлось бы целиком держать в памяти
+			attachments := items.Group(":id/attachments")
+			{
+				attachments.POST("/", h.uploadAttachment)
+				attachments.GET("/", h.getAllAttachments)
+				attachments.GET("/:attachment_id", h.downloadAttachment)
+				attachments.DELETE("/:attachment_id", h.deleteAttachment)
+			}
 		}
+
+		me := api.Group("me")
+		{
+			me.GET("/assigned", h.getAssignedItems)
+		}
+
+		trash := api.Group("trash")
+		{
+			trash.GET("/", h.getTrash)
+			trash.POST("/:type/:id/restore", h.restoreFromTrash)
+		}
+
+		reminders := api.Group("reminders")
+		{
+			reminders.DELETE("/:id", h.deleteReminder)
+		}
+
+		filters := api.Group("filters")
+		{
+			filters.POST("/", h.idempotent, h.createSavedFilter)
+			filters.GET("/", h.getAllSavedFilters)
+			filters.GET("/:id", h.getSavedFilterById)
+			filters.PUT("/:id", h.updateSavedFilter)
+			filters.DELETE("/:id", h.deleteSavedFilter)
+		}
+
+		webhooks := api.Group("webhooks")
+		{
+			webhooks.POST("/", h.idempotent, h.createWebhook)
+			webhooks.GET("/", h.getAllWebhooks)
+			webhooks.GET("/:id", h.getWebhookById)
+			webhooks.PUT("/:id", h.updateWebhook)
+			webhooks.DELETE("/:id", h.deleteWebhook)
+			webhooks.GET("/:id/deliveries", h.getWebhookDeliveries)
+		}
+
+		smartLists := api.Group("smart-lists")
+		{
+			smartLists.GET("/:id/items", h.getSmartListItems)
+		}
+
+		api.POST("/undo/:token", h.undo)
+		api.GET("/search", h.search)
+	}
+
+	// EventSource и WebSocket в браузере передают токен параметром, поэтому у
+	// потоков событий своя группа
+	events := router.Group("/api/events", tokenFromQuery, h.userIdentity)
+	{
+		events.GET("", h.streamEvents)
+		events.GET("/ws", h.eventsSocket)
+	}
+
+	// v2 отличается только форматом ответов, v1 остается для старых клиентов
+	v2 := router.Group("/api/v2", h.userIdentity)
+	{
+		lists := v2.Group("/lists")
+		{
+			lists.POST("", h.idempotent, h.createListV2)
+			lists.GET("", h.getAllListsV2)
+			lists.GET("/:id", h.getListByIdV2)
+			lists.PUT("/:id", h.updateListV2)
+			lists.PATCH("/:id", h.patchListV2)
+			lists.DELETE("/:id", h.deleteListV2)
+			lists.POST("/:id/items", h.idempotent, h.createItemV2)
+			lists.GET("/:id/items", h.getAllItemsV2)
+		}
+
+		items := v2.Group("/items")
+		{
+			items.GET("", h.getAllItemsForUserV2)
+			items.GET("/:id", h.getItemByIdV2)
+			items.PUT("/:id", h.updateItemV2)
+			items.PATCH("/:id", h.patchItemV2)
+			items.DELETE("/:id", h.deleteItemV2)
+		}
+
+		v2.GET("/smart-lists", h.getSmartListsV2)
 	}
 
 	return router
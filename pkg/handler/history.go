@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+)
+
+type historyResponse struct {
+	Data []todo.HistoryEntry `json:"data"`
+}
+
+func (h *Handler) getListHistory(c *gin.Context) {
+	h.getHistory(c, h.services.History.GetForList)
+}
+
+func (h *Handler) getItemHistory(c *gin.Context) {
+	h.getHistory(c, h.services.History.GetForItem)
+}
+
+func (h *Handler) getHistory(c *gin.Context, get func(userId, entityId, limit, offset int) ([]todo.HistoryEntry, error)) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid limit param")
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid offset param")
+		return
+	}
+
+	entries, err := get(userId, id, limit, offset)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, historyResponse{
+		Data: entries,
+	})
+}
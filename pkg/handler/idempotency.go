@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	// maxIdempotentBodySize ограничивает тело, которое читается в память ради
+	// отпечатка запроса
+	maxIdempotentBodySize = 1 << 20
+
+	// idempotencyTTL - сколько хранится ответ для повторов
+	idempotencyTTL = 24 * time.Hour
+	// idempotencyLockTTL ограничивает блокировку ключа, если обработчик упал,
+	// не успев сохранить ответ
+	idempotencyLockTTL = time.Minute
+)
+
+// idempotentResponse - запись о запросе с Idempotency-Key. Пока Status равен
+// нулю, первый запрос с этим ключом еще обрабатывается.
+type idempotentResponse struct {
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// заголовки ответа, которые повторяются вместе с телом
+var idempotentHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// responseRecorder копирует тело ответа, чтобы его можно было сохранить.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent сохраняет ответ на запрос с заголовком Idempotency-Key и отдает его
+// же на повторы, чтобы клиент мог безопасно повторить создание после обрыва
+// связи. Тот же ключ с другим запросом - 409. Ключи у каждого пользователя свои.
+// Если Redis недоступен, запрос обрабатывается как обычно. Тело читается в
+// память целиком, поэтому загрузка вложений через idempotent не проходит.
+func (h *Handler) idempotent(c *gin.Context) {
+	key := c.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		newErrorResponse(c, http.StatusBadRequest, "Idempotency-Key is too long")
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize)
+	body, err := io.ReadAll(c.Request.Body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		newErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds max size of %d bytes", maxIdempotentBodySize))
+		return
+	}
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	ctx := c.Request.Context()
+	// ключ хэшируется: Delete в кэше принимает шаблон, а ключ задает клиент
+	keyHash := sha256.Sum256([]byte(key))
+	cacheKey := fmt.Sprintf("idempotency:user:%d:%s", userId, hex.EncodeToString(keyHash[:]))
+	record := idempotentResponse{Fingerprint: requestFingerprint(c.Request, body)}
+
+	locked, err := h.cache.SetNX(ctx, cacheKey, record, idempotencyLockTTL)
+	if err != nil {
+		logrus.Errorf("failed to lock idempotency key: %v", err)
+		return
+	}
+	if !locked {
+		h.replayIdempotent(c, cacheKey, record.Fingerprint)
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	c.Next()
+
+	// после 5xx повтор должен выполниться заново
+	if recorder.Status() >= http.StatusInternalServerError {
+		if err := h.cache.Delete(ctx, cacheKey); err != nil {
+			logrus.Errorf("failed to release idempotency key: %v", err)
+		}
+		return
+	}
+
+	record.Status = recorder.Status()
+	record.Body = recorder.body.Bytes()
+	record.Headers = make(map[string]string)
+	for _, name := range idempotentHeaders {
+		if value := recorder.Header().Get(name); value != "" {
+			record.Headers[name] = value
+		}
+	}
+	if err := h.cache.Set(ctx, cacheKey, record, idempotencyTTL); err != nil {
+		logrus.Errorf("failed to save idempotent response: %v", err)
+	}
+}
+
+// replayIdempotent отвечает на повтор запроса сохраненным ответом.
+func (h *Handler) replayIdempotent(c *gin.Context, cacheKey, fingerprint string) {
+	value, err := h.cache.Get(c.Request.Context(), cacheKey)
+	if err != nil {
+		// запись успела истечь между SetNX и Get
+		newErrorResponse(c, http.StatusConflict, "request with this Idempotency-Key is still in progress")
+		return
+	}
+
+	var record idempotentResponse
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	switch {
+	case record.Fingerprint != fingerprint:
+		newErrorResponse(c, http.StatusConflict, "Idempotency-Key was already used with a different request")
+	case record.Status == 0:
+		newErrorResponse(c, http.StatusConflict, "request with this Idempotency-Key is still in progress")
+	default:
+		for name, value := range record.Headers {
+			c.Header(name, value)
+		}
+		c.Header(idempotencyReplayedHeader, "true")
+		c.Data(record.Status, record.Headers["Content-Type"], record.Body)
+		c.Abort()
+	}
+}
+
+// requestFingerprint отличает запросы с одним ключом: метод, путь и тело.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", r.Method, r.URL.Path)
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryCache - cache.Cache в памяти; Delete понимает только точные ключи.
+type memoryCache map[string]string
+
+func (m memoryCache) Get(ctx context.Context, key string) (string, error) {
+	value, ok := m[key]
+	if !ok {
+		return "", errors.New("cache miss")
+	}
+	return value, nil
+}
+
+func (m memoryCache) Set(ctx context.Context, key string, value any, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m[key] = string(data)
+	return nil
+}
+
+func (m memoryCache) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	if _, ok := m[key]; ok {
+		return false, nil
+	}
+	return true, m.Set(ctx, key, value, expiration)
+}
+
+func (m memoryCache) Delete(ctx context.Context, pattern string) error {
+	delete(m, pattern)
+	return nil
+}
+
+func TestIdempotent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{cache: memoryCache{}}
+
+	calls := 0
+	router := gin.New()
+	router.POST("/lists", func(c *gin.Context) { c.Set(userCtx, 1) }, h.idempotent, func(c *gin.Context) {
+		calls++
+		c.Header("Location", "/lists/1")
+		c.JSON(http.StatusCreated, map[string]int{"id": calls})
+	})
+	router.POST("/fail", func(c *gin.Context) { c.Set(userCtx, 1) }, h.idempotent, func(c *gin.Context) {
+		calls++
+		newErrorResponse(c, http.StatusInternalServerError, "boom")
+	})
+
+	send := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("/lists", "key-1", `{"title":"a"}`)
+	assert.Equal(t, http.StatusCreated, first.Code, "unexpected status")
+
+	retry := send("/lists", "key-1", `{"title":"a"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code, "expected replayed status")
+	assert.Equal(t, first.Body.String(), retry.Body.String(), "expected replayed body")
+	assert.Equal(t, "/lists/1", retry.Header().Get("Location"), "expected replayed Location")
+	assert.Equal(t, "true", retry.Header().Get(idempotencyReplayedHeader), "expected replay marker")
+	assert.Equal(t, 1, calls, "expected handler to run once")
+
+	reused := send("/lists", "key-1", `{"title":"b"}`)
+	assert.Equal(t, http.StatusConflict, reused.Code, "expected conflict for different body")
+	assert.Equal(t, 1, calls, "expected handler not to run for reused key")
+
+	send("/lists", "", `{"title":"a"}`)
+	send("/lists", "", `{"title":"a"}`)
+	assert.Equal(t, 3, calls, "expected requests without key to run every time")
+
+	send("/fail", "key-2", `{}`)
+	send("/fail", "key-2", `{}`)
+	assert.Equal(t, 5, calls, "expected failed request to be retried")
+
+	tooLarge := send("/lists", "key-3", `{"title":"`+strings.Repeat("a", maxIdempotentBodySize)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, tooLarge.Code, "expected body limit")
+	assert.Equal(t, 5, calls, "expected handler not to run for oversized body")
+}
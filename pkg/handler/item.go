@@ -2,7 +2,9 @@ package handler
 
 import (
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	todo "github.com/balamuteon/todo_restapi"
 	"github.com/gin-gonic/gin"
@@ -21,13 +23,13 @@ func (h *Handler) createItem(c *gin.Context) {
 	}
 
 	var input todo.TodoItem
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	if err := bindJSON(c, &input); err != nil {
+		return
 	}
 
 	id, err := h.services.TodoItem.Create(userId, listId, input)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newServiceErrorResponse(c, err)
 		return
 	}
 
@@ -36,6 +38,11 @@ func (h *Handler) createItem(c *gin.Context) {
 	})
 }
 
+type getAllItemsResponse struct {
+	Data       []todo.TodoItem `json:"data"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
 func (h *Handler) getAllItems(c *gin.Context) {
 	userId, err := getUserId(c)
 	if err != nil {
@@ -48,13 +55,41 @@ func (h *Handler) getAllItems(c *gin.Context) {
 		return
 	}
 
-	items, err := h.services.TodoItem.GetAll(userId, listId)
+	page, err := getPage(c)
+	if err != nil {
+		return
+	}
+
+	filter, err := getItemFilter(c)
+	if err != nil {
+		return
+	}
+
+	// без limit и cursor v1 отдает все задачи массивом, как до появления
+	// пагинации; страницы с next_cursor - только по явному запросу
+	if page == (todo.PageInput{}) {
+		items, err := allPages(func(page todo.PageInput) ([]todo.TodoItem, string, error) {
+			return h.services.TodoItem.GetAll(userId, listId, filter, page)
+		})
+		if err != nil {
+			newServiceErrorResponse(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, items)
+		return
+	}
+
+	items, nextCursor, err := h.services.TodoItem.GetAll(userId, listId, filter, page)
 	if err != nil {
-		newErrorResponse(c, http.StatusNotFound, err.Error())
+		newServiceErrorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, items)
+	c.JSON(http.StatusOK, getAllItemsResponse{
+		Data:       items,
+		NextCursor: nextCursor,
+	})
 }
 
 func (h *Handler) getItemById(c *gin.Context) {
@@ -71,7 +106,11 @@ func (h *Handler) getItemById(c *gin.Context) {
 
 	item, err := h.services.TodoItem.GetById(userId, itemId)
 	if err != nil {
-		newErrorResponse(c, http.StatusNotFound, err.Error())
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	if notModified(c, item.Version) {
 		return
 	}
 
@@ -90,18 +129,55 @@ func (h *Handler) updateItem(c *gin.Context) {
 		return
 	}
 
+	version, err := getIfMatch(c)
+	if err != nil {
+		return
+	}
+
 	var input todo.UpdateItemInput
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	if err := bindJSON(c, &input); err != nil {
+		return
+	}
+	input.Version = version
+
+	undoToken, err := h.services.TodoItem.Update(userId, id, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, undoResponse{Status: "ok", UndoToken: undoToken})
+}
+
+func (h *Handler) patchItem(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	version, err := getIfMatch(c)
+	if err != nil {
 		return
 	}
 
-	if err := h.services.TodoItem.Update(userId, id, input); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	contentType, body, err := getPatch(c)
+	if err != nil {
 		return
 	}
 
-	c.JSON(http.StatusOK, statusResponse{"ok"})
+	undoToken, err := h.services.TodoItem.Patch(userId, id, contentType, body, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, undoResponse{Status: "ok", UndoToken: undoToken})
 }
 
 func (h *Handler) deleteItem(c *gin.Context) {
@@ -116,10 +192,129 @@ func (h *Handler) deleteItem(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.TodoItem.Delete(userId, itemId); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	version, err := getIfMatch(c)
+	if err != nil {
+		return
+	}
+
+	undoToken, err := h.services.TodoItem.Delete(userId, itemId, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, undoResponse{Status: "ok", UndoToken: undoToken})
+}
+
+func (h *Handler) assignItem(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	version, err := getIfMatch(c)
+	if err != nil {
+		return
+	}
+
+	var input todo.AssignItemInput
+	if err := bindJSON(c, &input); err != nil {
+		return
+	}
+
+	if err := h.services.TodoItem.Assign(userId, itemId, input.AssigneeId, version); err != nil {
+		newServiceErrorResponse(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, statusResponse{Status: "ok"})
 }
+
+func (h *Handler) getAssignedItems(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	page, err := getPage(c)
+	if err != nil {
+		return
+	}
+
+	// без limit и cursor - все задачи массивом по сроку, как до появления
+	// пагинации; страницы идут по id, как и в остальных коллекциях
+	if page == (todo.PageInput{}) {
+		items, err := allPages(func(page todo.PageInput) ([]todo.ItemWithList, string, error) {
+			return h.services.TodoItem.GetAssigned(userId, page)
+		})
+		if err != nil {
+			newServiceErrorResponse(c, err)
+			return
+		}
+
+		sort.SliceStable(items, func(i, j int) bool { return dueBefore(items[i].DueAt, items[j].DueAt) })
+		c.JSON(http.StatusOK, items)
+		return
+	}
+
+	items, nextCursor, err := h.services.TodoItem.GetAssigned(userId, page)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, getAllItemsForUserResponse{
+		Data:       items,
+		NextCursor: nextCursor,
+	})
+}
+
+// dueBefore сравнивает сроки как ORDER BY due_at NULLS LAST.
+func dueBefore(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a != nil && b == nil
+	}
+
+	return a.Before(*b)
+}
+
+type getAllItemsForUserResponse struct {
+	Data       []todo.ItemWithList `json:"data"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// getAllItemsForUser отдает задачи из всех списков пользователя. Принимает те же
+// фильтры и пагинацию, что и задачи списка, а также выражение в параметре query.
+func (h *Handler) getAllItemsForUser(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	page, err := getPage(c)
+	if err != nil {
+		return
+	}
+
+	filter, err := getItemFilter(c)
+	if err != nil {
+		return
+	}
+
+	items, nextCursor, err := h.services.TodoItem.GetAllForUser(userId, filter, c.Query("query"), page)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, getAllItemsForUserResponse{
+		Data:       items,
+		NextCursor: nextCursor,
+	})
+}
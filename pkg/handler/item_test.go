@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeItems отдает задачи 1..total страницами по курсору.
+type fakeItems struct {
+	service.TodoItem
+	total int
+}
+
+func (f *fakeItems) GetAll(userId, listId int, filter todo.ItemFilter, page todo.PageInput) ([]todo.TodoItem, string, error) {
+	afterId, err := page.AfterId()
+	if err != nil {
+		return nil, "", err
+	}
+
+	items := make([]todo.TodoItem, 0)
+	for id := afterId + 1; id <= f.total && len(items) < page.Size(); id++ {
+		items = append(items, todo.TodoItem{Id: id, Title: "item"})
+	}
+
+	var nextCursor string
+	if len(items) > 0 && items[len(items)-1].Id < f.total {
+		nextCursor = todo.EncodeCursor(items[len(items)-1].Id)
+	}
+
+	return items, nextCursor, nil
+}
+
+func TestGetAllItems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		h := &Handler{services: &service.Service{TodoItem: &fakeItems{total: todo.MaxPageLimit + 5}}}
+		router := gin.New()
+		router.GET("/lists/:id/items", func(c *gin.Context) { c.Set(userCtx, 1) }, h.getAllItems)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lists/1/items"+query, nil))
+
+		return w
+	}
+
+	t.Run("returns bare array of all items without pagination params", func(t *testing.T) {
+		w := get("")
+		assert.Equal(t, http.StatusOK, w.Code, "expected 200")
+
+		var items []todo.TodoItem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &items), "expected JSON array")
+		assert.Len(t, items, todo.MaxPageLimit+5, "expected items from every page")
+	})
+
+	t.Run("returns page with cursor when limit is given", func(t *testing.T) {
+		w := get("?limit=2")
+		assert.Equal(t, http.StatusOK, w.Code, "expected 200")
+
+		var response getAllItemsResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "expected page envelope")
+		assert.Len(t, response.Data, 2, "expected one page")
+		assert.Equal(t, todo.EncodeCursor(2), response.NextCursor, "expected next cursor")
+	})
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) createItemV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid list id param")
+		return
+	}
+
+	var input todo.TodoItem
+	if err := bindJSON(c, &input); err != nil {
+		return
+	}
+
+	id, err := h.services.TodoItem.Create(userId, listId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	item, err := h.services.TodoItem.GetById(userId, id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(item.Version))
+	created(c, "/api/v2/items/%d", id, item)
+}
+
+func (h *Handler) getAllItemsV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid list id param")
+		return
+	}
+
+	page, err := getPage(c)
+	if err != nil {
+		return
+	}
+
+	filter, err := getItemFilter(c)
+	if err != nil {
+		return
+	}
+
+	items, nextCursor, err := h.services.TodoItem.GetAll(userId, listId, filter, page)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(items, page, nextCursor))
+}
+
+func (h *Handler) getAllItemsForUserV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	page, err := getPage(c)
+	if err != nil {
+		return
+	}
+
+	filter, err := getItemFilter(c)
+	if err != nil {
+		return
+	}
+
+	items, nextCursor, err := h.services.TodoItem.GetAllForUser(userId, filter, c.Query("query"), page)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(items, page, nextCursor))
+}
+
+func (h *Handler) getItemByIdV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	item, err := h.services.TodoItem.GetById(userId, itemId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	if notModified(c, item.Version) {
+		return
+	}
+
+	c.JSON(http.StatusOK, resourceResponse[todo.TodoItem]{Data: item})
+}
+
+func (h *Handler) updateItemV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	version, err := requireIfMatch(c)
+	if err != nil {
+		return
+	}
+
+	var input todo.UpdateItemInput
+	if err := bindJSON(c, &input); err != nil {
+		return
+	}
+	input.Version = version
+
+	undoToken, err := h.services.TodoItem.Update(userId, itemId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	item, err := h.services.TodoItem.GetById(userId, itemId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(item.Version))
+	c.JSON(http.StatusOK, resourceResponse[todo.TodoItem]{Data: item, Meta: undoMeta(undoToken)})
+}
+
+func (h *Handler) patchItemV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	version, err := requireIfMatch(c)
+	if err != nil {
+		return
+	}
+
+	contentType, body, err := getPatch(c)
+	if err != nil {
+		return
+	}
+
+	undoToken, err := h.services.TodoItem.Patch(userId, itemId, contentType, body, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	item, err := h.services.TodoItem.GetById(userId, itemId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(item.Version))
+	c.JSON(http.StatusOK, resourceResponse[todo.TodoItem]{Data: item, Meta: undoMeta(undoToken)})
+}
+
+func (h *Handler) deleteItemV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid item id param")
+		return
+	}
+
+	version, err := requireIfMatch(c)
+	if err != nil {
+		return
+	}
+
+	item, err := h.services.TodoItem.GetById(userId, itemId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	undoToken, err := h.services.TodoItem.Delete(userId, itemId, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resourceResponse[todo.TodoItem]{Data: item, Meta: undoMeta(undoToken)})
+}
@@ -17,17 +17,15 @@ func (h *Handler) createList(c *gin.Context) {
 	if err != nil {
 		return
 	}
-	defer h.invalidateListCache(c, userId)
 
 	var input todo.TodoList
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	if err := bindJSON(c, &input); err != nil {
 		return
 	}
 
 	id, err := h.services.TodoList.Create(userId, input)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newServiceErrorResponse(c, err)
 		return
 	}
 
@@ -36,8 +34,12 @@ func (h *Handler) createList(c *gin.Context) {
 	})
 }
 
+// getAllListsResponse отдает умные списки вместе с обычными на каждой
+// странице: их немного, и они не участвуют в пагинации.
 type getAllListsResponse struct {
-	Data []todo.TodoList `json:"data"`
+	Data       []todo.TodoList  `json:"data"`
+	SmartLists []todo.SmartList `json:"smart_lists"`
+	NextCursor string           `json:"next_cursor,omitempty"`
 }
 
 func (h *Handler) getAllLists(c *gin.Context) {
@@ -46,34 +48,69 @@ func (h *Handler) getAllLists(c *gin.Context) {
 		return
 	}
 
+	page, err := getPage(c)
+	if err != nil {
+		return
+	}
+
+	// без limit и cursor v1 отдает все списки, как до появления пагинации;
+	// страницы с next_cursor - только по явному запросу
+	response, err := h.loadAllLists(c, userId, page, page == (todo.PageInput{}))
+	if err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// loadAllLists отдает страницу списков, а при all - все списки, из кэша или из
+// сервиса; при ошибке ответ уже отправлен.
+func (h *Handler) loadAllLists(c *gin.Context, userId int, page todo.PageInput, all bool) (getAllListsResponse, error) {
+	var response getAllListsResponse
 	ctx := c.Request.Context()
-	cacheKey := fmt.Sprintf("user:%d:lists", userId)
+	cacheKey := fmt.Sprintf("%s?limit=%d&cursor=%s", cache.UserListsKey(userId), page.Size(), page.Cursor)
+	if all {
+		cacheKey = cache.UserListsKey(userId) + "?all"
+	}
 	cacheValue, err := h.cache.Get(ctx, cacheKey)
 	if err == nil {
-		var lists []todo.TodoList
-		if err := json.Unmarshal([]byte(cacheValue), &lists); err != nil {
+		if err := json.Unmarshal([]byte(cacheValue), &response); err != nil {
 			newErrorResponse(c, http.StatusInternalServerError, err.Error())
-			return
+			return response, err
 		}
 
-		c.JSON(http.StatusOK, getAllListsResponse{
-			Data: lists,
-		})
 		logrus.Debug("got from cache")
-		return
+		return response, nil
 	}
 
-	lists, err := h.services.TodoList.GetAll(userId)
+	var lists []todo.TodoList
+	var nextCursor string
+	if all {
+		lists, err = allPages(func(page todo.PageInput) ([]todo.TodoList, string, error) {
+			return h.services.TodoList.GetAll(userId, page)
+		})
+	} else {
+		lists, nextCursor, err = h.services.TodoList.GetAll(userId, page)
+	}
 	if err != nil {
-		newErrorResponse(c, http.StatusNotFound, err.Error())
-		return
+		newServiceErrorResponse(c, err)
+		return response, err
+	}
+
+	smartLists, err := h.services.SmartList.GetAll(userId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return response, err
 	}
 
-	h.cache.Set(ctx, cacheKey, lists, cache.CacheTTL)
+	response = getAllListsResponse{
+		Data:       lists,
+		SmartLists: smartLists,
+		NextCursor: nextCursor,
+	}
+	h.cache.Set(ctx, cacheKey, response, cache.CacheTTL)
 
-	c.JSON(http.StatusOK, getAllListsResponse{
-		Data: lists,
-	})
+	return response, nil
 }
 
 func (h *Handler) getListById(c *gin.Context) {
@@ -88,29 +125,42 @@ func (h *Handler) getListById(c *gin.Context) {
 		return
 	}
 
+	list, err := h.loadList(c, userId, id)
+	if err != nil {
+		return
+	}
+
+	if notModified(c, list.Version) {
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// loadList отдает список из кэша или из сервиса; при ошибке ответ уже отправлен.
+func (h *Handler) loadList(c *gin.Context, userId, id int) (todo.TodoList, error) {
 	var list todo.TodoList
 	ctx := c.Request.Context()
-	cacheKey := fmt.Sprintf("user:%d:lists:%d", userId, id)
+	cacheKey := fmt.Sprintf("%s:%d", cache.UserListsKey(userId), id)
 	cacheValue, err := h.cache.Get(ctx, cacheKey)
 	if err == nil {
 		if err := json.Unmarshal([]byte(cacheValue), &list); err != nil {
 			newErrorResponse(c, http.StatusInternalServerError, err.Error())
-			return
+			return list, err
 		}
-		c.JSON(http.StatusOK, list)
 		logrus.Debug("got from cache")
-		return
+		return list, nil
 	}
 
 	list, err = h.services.TodoList.GetById(userId, id)
 	if err != nil {
-		newErrorResponse(c, http.StatusNotFound, err.Error())
-		return
+		newServiceErrorResponse(c, err)
+		return list, err
 	}
 
 	h.cache.Set(ctx, cacheKey, list, cache.CacheTTL)
 
-	c.JSON(http.StatusOK, list)
+	return list, nil
 }
 
 func (h *Handler) updateList(c *gin.Context) {
@@ -118,7 +168,6 @@ func (h *Handler) updateList(c *gin.Context) {
 	if err != nil {
 		return
 	}
-	defer h.invalidateListCache(c, userId)
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -126,26 +175,31 @@ func (h *Handler) updateList(c *gin.Context) {
 		return
 	}
 
+	version, err := getIfMatch(c)
+	if err != nil {
+		return
+	}
+
 	var input todo.UpdateListInput
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	if err := bindJSON(c, &input); err != nil {
 		return
 	}
+	input.Version = version
 
-	if err := h.services.TodoList.Update(userId, id, input); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	undoToken, err := h.services.TodoList.Update(userId, id, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, statusResponse{"ok"})
+	c.JSON(http.StatusOK, undoResponse{Status: "ok", UndoToken: undoToken})
 }
 
-func (h *Handler) deleteList(c *gin.Context) {
+func (h *Handler) patchList(c *gin.Context) {
 	userId, err := getUserId(c)
 	if err != nil {
 		return
 	}
-	defer h.invalidateListCache(c, userId)
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -153,23 +207,50 @@ func (h *Handler) deleteList(c *gin.Context) {
 		return
 	}
 
-	err = h.services.TodoList.Delete(userId, id)
+	version, err := getIfMatch(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, statusResponse{
-		Status: "ok",
-	})
+	contentType, body, err := getPatch(c)
+	if err != nil {
+		return
+	}
+
+	undoToken, err := h.services.TodoList.Patch(userId, id, contentType, body, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, undoResponse{Status: "ok", UndoToken: undoToken})
 }
 
-func (h *Handler) invalidateListCache(c *gin.Context, userId int) {
-	ctx := c.Request.Context()
-	cachePattern := fmt.Sprintf("user:%d:lists*", userId)
-	if err := h.cache.Delete(ctx, cachePattern); err != nil {
-		logrus.Errorf("failed to invalidate cache: %v", err)
-	} else {
-		logrus.Debug("cache invalidated")
+func (h *Handler) deleteList(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	version, err := getIfMatch(c)
+	if err != nil {
+		return
 	}
+
+	undoToken, err := h.services.TodoList.Delete(userId, id, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, undoResponse{
+		Status:    "ok",
+		UndoToken: undoToken,
+	})
 }
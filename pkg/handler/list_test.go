@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLists отдает списки 1..total страницами по курсору.
+type fakeLists struct {
+	service.TodoList
+	total int
+}
+
+func (f *fakeLists) GetAll(userId int, page todo.PageInput) ([]todo.TodoList, string, error) {
+	afterId, err := page.AfterId()
+	if err != nil {
+		return nil, "", err
+	}
+
+	lists := make([]todo.TodoList, 0)
+	for id := afterId + 1; id <= f.total && len(lists) < page.Size(); id++ {
+		lists = append(lists, todo.TodoList{Id: id, Title: "list"})
+	}
+
+	var nextCursor string
+	if len(lists) > 0 && lists[len(lists)-1].Id < f.total {
+		nextCursor = todo.EncodeCursor(lists[len(lists)-1].Id)
+	}
+
+	return lists, nextCursor, nil
+}
+
+type fakeSmartLists struct {
+	service.SmartList
+}
+
+func (f *fakeSmartLists) GetAll(userId int) ([]todo.SmartList, error) {
+	return []todo.SmartList{}, nil
+}
+
+func TestGetAllLists(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		h := &Handler{
+			services: &service.Service{TodoList: &fakeLists{total: todo.MaxPageLimit + 5}, SmartList: &fakeSmartLists{}},
+			cache:    memoryCache{},
+		}
+		router := gin.New()
+		router.GET("/lists", func(c *gin.Context) { c.Set(userCtx, 1) }, h.getAllLists)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lists"+query, nil))
+
+		return w
+	}
+
+	t.Run("returns all lists without pagination params", func(t *testing.T) {
+		w := get("")
+		assert.Equal(t, http.StatusOK, w.Code, "expected 200")
+
+		var response getAllListsResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "expected JSON object")
+		assert.Len(t, response.Data, todo.MaxPageLimit+5, "expected lists from every page")
+		assert.Empty(t, response.NextCursor, "expected no cursor")
+	})
+
+	t.Run("returns page with cursor when limit is given", func(t *testing.T) {
+		w := get("?limit=2")
+		assert.Equal(t, http.StatusOK, w.Code, "expected 200")
+
+		var response getAllListsResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "expected JSON object")
+		assert.Len(t, response.Data, 2, "expected one page")
+		assert.Equal(t, todo.EncodeCursor(2), response.NextCursor, "expected next cursor")
+	})
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) createListV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	var input todo.TodoList
+	if err := bindJSON(c, &input); err != nil {
+		return
+	}
+
+	id, err := h.services.TodoList.Create(userId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	list, err := h.services.TodoList.GetById(userId, id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(list.Version))
+	created(c, "/api/v2/lists/%d", id, list)
+}
+
+// getAllListsV2 отдает только обычные списки, умные - по /api/v2/smart-lists.
+func (h *Handler) getAllListsV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	page, err := getPage(c)
+	if err != nil {
+		return
+	}
+
+	response, err := h.loadAllLists(c, userId, page, false)
+	if err != nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, newPageResponse(response.Data, page, response.NextCursor))
+}
+
+func (h *Handler) getListByIdV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	list, err := h.loadList(c, userId, id)
+	if err != nil {
+		return
+	}
+
+	if notModified(c, list.Version) {
+		return
+	}
+
+	c.JSON(http.StatusOK, resourceResponse[todo.TodoList]{Data: list})
+}
+
+func (h *Handler) updateListV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	version, err := requireIfMatch(c)
+	if err != nil {
+		return
+	}
+
+	var input todo.UpdateListInput
+	if err := bindJSON(c, &input); err != nil {
+		return
+	}
+	input.Version = version
+
+	undoToken, err := h.services.TodoList.Update(userId, id, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	list, err := h.services.TodoList.GetById(userId, id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(list.Version))
+	c.JSON(http.StatusOK, resourceResponse[todo.TodoList]{Data: list, Meta: undoMeta(undoToken)})
+}
+
+func (h *Handler) patchListV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	version, err := requireIfMatch(c)
+	if err != nil {
+		return
+	}
+
+	contentType, body, err := getPatch(c)
+	if err != nil {
+		return
+	}
+
+	undoToken, err := h.services.TodoList.Patch(userId, id, contentType, body, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	list, err := h.services.TodoList.GetById(userId, id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(list.Version))
+	c.JSON(http.StatusOK, resourceResponse[todo.TodoList]{Data: list, Meta: undoMeta(undoToken)})
+}
+
+// deleteListV2 возвращает удаленный список, чтобы клиент мог показать, что
+// именно можно отменить.
+func (h *Handler) deleteListV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	version, err := requireIfMatch(c)
+	if err != nil {
+		return
+	}
+
+	list, err := h.services.TodoList.GetById(userId, id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	undoToken, err := h.services.TodoList.Delete(userId, id, version)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resourceResponse[todo.TodoList]{Data: list, Meta: undoMeta(undoToken)})
+}
+
+func (h *Handler) getSmartListsV2(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	smartLists, err := h.services.SmartList.GetAll(userId)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collectionResponse[todo.SmartList]{Data: smartLists})
+}
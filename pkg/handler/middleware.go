@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"net/http"
 	"strings"
@@ -10,9 +12,41 @@ import (
 
 const (
 	authorizationHeader = "Authorization"
+	requestIdHeader     = "X-Request-ID"
 	userCtx             = "userId"
+	requestIdCtx        = "requestId"
 )
 
+// requestId берет идентификатор запроса из заголовка X-Request-ID или создает
+// новый и возвращает его в ответе, чтобы ошибку клиента можно было найти в логах.
+func requestId(c *gin.Context) {
+	id := c.GetHeader(requestIdHeader)
+	if !validRequestId(id) {
+		buf := make([]byte, 16)
+		rand.Read(buf)
+		id = hex.EncodeToString(buf)
+	}
+
+	c.Set(requestIdCtx, id)
+	c.Header(requestIdHeader, id)
+}
+
+// validRequestId пропускает только короткие идентификаторы из безопасных
+// символов: значение попадает в логи.
+func validRequestId(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (h *Handler) userIdentity(c *gin.Context) {
 	header := c.GetHeader(authorizationHeader)
 	if header == "" {
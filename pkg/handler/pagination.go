@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+)
+
+// getPage читает параметры limit и cursor; при ошибке ответ уже отправлен.
+func getPage(c *gin.Context) (todo.PageInput, error) {
+	var page todo.PageInput
+	if err := c.ShouldBindQuery(&page); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid limit param")
+		return page, err
+	}
+
+	if err := page.Validate(); err != nil {
+		newServiceErrorResponse(c, err)
+		return page, err
+	}
+
+	return page, nil
+}
+
+// allPages собирает коллекцию со всех страниц. Им v1 отвечает на запросы без
+// limit и cursor: до появления пагинации коллекции отдавались целиком.
+func allPages[T any](load func(page todo.PageInput) ([]T, string, error)) ([]T, error) {
+	rows := make([]T, 0)
+	page := todo.PageInput{Limit: todo.MaxPageLimit}
+	for {
+		chunk, nextCursor, err := load(page)
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, chunk...)
+		if nextCursor == "" {
+			return rows, nil
+		}
+		page.Cursor = nextCursor
+	}
+}
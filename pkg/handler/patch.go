@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/balamuteon/todo_restapi/pkg/patch"
+	"github.com/gin-gonic/gin"
+)
+
+// getPatch читает тело PATCH-запроса; формат определяется по Content-Type.
+// При ошибке ответ уже отправлен.
+func getPatch(c *gin.Context) (string, []byte, error) {
+	contentType := c.ContentType()
+	if contentType != patch.MergePatchType && contentType != patch.JSONPatchType {
+		c.Header("Accept-Patch", patch.MergePatchType+", "+patch.JSONPatchType)
+		newErrorResponse(c, http.StatusUnsupportedMediaType, "content type must be "+patch.MergePatchType+" or "+patch.JSONPatchType)
+		return "", nil, errors.New("unsupported patch content type")
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "failed to read request body")
+		return "", nil, err
+	}
+
+	return contentType, body, nil
+}
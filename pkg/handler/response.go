@@ -1,19 +1,167 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+	"strings"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/query"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
-type errorResponse struct {
-	Message string `json:""`
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:todo:problem:"
+)
+
+// Стабильные коды ошибок. Клиенты опираются на них, а не на текст detail,
+// поэтому менять существующие коды нельзя.
+const (
+	codeBadRequest   = "bad_request"
+	codeValidation   = "validation_failed"
+	codeInvalidQuery = "invalid_query"
+	codeUnauthorized = "unauthorized"
+	codeForbidden    = "forbidden"
+	codeNotFound     = "not_found"
+	codeConflict     = "conflict"
+	codePrecondition = "precondition_failed"
+	codeInternal     = "internal_error"
+)
+
+// problem - тело ошибки в формате RFC 7807 (application/problem+json).
+type problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Code      string       `json:"code"`
+	RequestId string       `json:"request_id,omitempty"`
+	Errors    []fieldError `json:"errors,omitempty"`
+	// Position - позиция ошибки в выражении фильтра, в символах от нуля
+	Position *int `json:"position,omitempty"`
+}
+
+// fieldError описывает ошибку в одном поле тела запроса.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type statusResponse struct {
 	Status string `json:"status"`
 }
 
+// newErrorResponse отправляет ошибку с сообщением, которое выбрал обработчик.
+// Для 5xx сообщение только пишется в лог, клиенту уходит общий текст.
 func newErrorResponse(c *gin.Context, statusCode int, message string) {
-	logrus.Error(message)
-	c.AbortWithStatusJSON(statusCode, errorResponse{message})
+	abortWithProblem(c, problem{
+		Status: statusCode,
+		Code:   statusErrorCode(statusCode),
+		Detail: message,
+	}, message)
+}
+
+// newServiceErrorResponse отправляет ошибку сервиса. Текст ошибок без категории
+// (например, ошибок БД) клиенту не показывается.
+func newServiceErrorResponse(c *gin.Context, err error) {
+	abortWithProblem(c, serviceProblem(err), err.Error())
+}
+
+// serviceProblem выбирает статус и код по категории ошибки сервиса. Ошибки
+// разбора выражения фильтра дополняются позицией.
+func serviceProblem(err error) problem {
+	var queryErr *query.Error
+	if errors.As(err, &queryErr) {
+		return problem{
+			Status:   http.StatusBadRequest,
+			Code:     codeInvalidQuery,
+			Detail:   queryErr.Message,
+			Position: &queryErr.Offset,
+		}
+	}
+
+	status := errorStatus(err)
+	return problem{
+		Status: status,
+		Code:   errorCode(err, status),
+		Detail: err.Error(),
+	}
+}
+
+// abortWithProblem отправляет ошибку и прерывает обработку запроса.
+func abortWithProblem(c *gin.Context, p problem, cause string) {
+	p = completeProblem(c, p, cause)
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// completeProblem дописывает общие поля и пишет причину в лог. Для 5xx клиенту
+// уходит общий текст.
+func completeProblem(c *gin.Context, p problem, cause string) problem {
+	p.Type = problemTypePrefix + p.Code
+	p.Title = http.StatusText(p.Status)
+	p.RequestId = c.GetString(requestIdCtx)
+
+	log := logrus.WithFields(logrus.Fields{
+		"request_id": p.RequestId,
+		"status":     p.Status,
+		"code":       p.Code,
+	})
+	if p.Status >= http.StatusInternalServerError {
+		log.Error(cause)
+		p.Detail = "internal server error"
+	} else {
+		log.Warn(cause)
+	}
+
+	return p
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, todo.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, todo.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, todo.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, todo.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, todo.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func errorCode(err error, status int) string {
+	if errors.Is(err, todo.ErrValidation) {
+		return codeValidation
+	}
+
+	return statusErrorCode(status)
+}
+
+func statusErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return codeBadRequest
+	case http.StatusUnauthorized:
+		return codeUnauthorized
+	case http.StatusForbidden:
+		return codeForbidden
+	case http.StatusNotFound:
+		return codeNotFound
+	case http.StatusConflict:
+		return codeConflict
+	case http.StatusPreconditionFailed:
+		return codePrecondition
+	case http.StatusInternalServerError:
+		return codeInternal
+	default:
+		return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
 }
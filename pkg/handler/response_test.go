@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveProblem(t *testing.T, body string, handler gin.HandlerFunc) (*httptest.ResponseRecorder, problem) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(requestId)
+	router.POST("/", handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(requestIdHeader, "test-request")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var p problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p), "expected problem body")
+	return w, p
+}
+
+func TestNewBindErrorResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		code   string
+		fields []fieldError
+	}{
+		{
+			name:   "missing required field",
+			body:   `{"description": "x"}`,
+			code:   codeValidation,
+			fields: []fieldError{{Field: "title", Message: "is required"}},
+		},
+		{
+			name:   "wrong field type",
+			body:   `{"title": 1}`,
+			code:   codeValidation,
+			fields: []fieldError{{Field: "title", Message: "must be of type string"}},
+		},
+		{
+			name: "malformed body",
+			body: `{"title":`,
+			code: codeBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, p := serveProblem(t, tt.body, func(c *gin.Context) {
+				var input todo.TodoList
+				if err := bindJSON(c, &input); err != nil {
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "unexpected status")
+			assert.Equal(t, problemContentType, w.Header().Get("Content-Type"), "unexpected content type")
+			assert.Equal(t, tt.code, p.Code, "unexpected code")
+			assert.Equal(t, tt.fields, p.Errors, "unexpected field errors")
+			assert.Equal(t, "test-request", p.RequestId, "expected request id")
+		})
+	}
+}
+
+func TestNewServiceErrorResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{
+			name:   "typed error",
+			err:    todo.NewError(todo.ErrNotFound, "item not found"),
+			status: http.StatusNotFound,
+			code:   codeNotFound,
+			detail: "item not found",
+		},
+		{
+			name:   "validation error",
+			err:    todo.NewError(todo.ErrValidation, "title must not be empty"),
+			status: http.StatusBadRequest,
+			code:   codeValidation,
+			detail: "title must not be empty",
+		},
+		{
+			name:   "precondition failed",
+			err:    todo.NewError(todo.ErrPreconditionFailed, "item has been modified"),
+			status: http.StatusPreconditionFailed,
+			code:   codePrecondition,
+			detail: "item has been modified",
+		},
+		{
+			name:   "internal error is not leaked",
+			err:    errors.New(`pq: relation "todo_items" does not exist`),
+			status: http.StatusInternalServerError,
+			code:   codeInternal,
+			detail: "internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, p := serveProblem(t, "", func(c *gin.Context) {
+				newServiceErrorResponse(c, tt.err)
+			})
+
+			assert.Equal(t, tt.status, w.Code, "unexpected status")
+			assert.Equal(t, tt.status, p.Status, "unexpected status in body")
+			assert.Equal(t, tt.code, p.Code, "unexpected code")
+			assert.Equal(t, problemTypePrefix+tt.code, p.Type, "unexpected type")
+			assert.Equal(t, tt.detail, p.Detail, "unexpected detail")
+		})
+	}
+}
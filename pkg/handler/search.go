@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/gin-gonic/gin"
+)
+
+type searchResponse struct {
+	Data []todo.SearchHit `json:"data"`
+}
+
+func (h *Handler) search(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	var input todo.SearchInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid limit param")
+		return
+	}
+
+	hits, err := h.services.Search.Search(userId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, searchResponse{Data: hits})
+}
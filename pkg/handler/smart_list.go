@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/cache"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// smartListCacheTTL короче обычного: встроенные списки вроде "Overdue" зависят
+// от текущего времени и устаревают даже без изменений задач.
+const smartListCacheTTL = time.Minute
+
+type smartListItemsResponse struct {
+	Data       []todo.ItemWithList `json:"data"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+func (h *Handler) getSmartListItems(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	page, err := getPage(c)
+	if err != nil {
+		return
+	}
+
+	smartListId := c.Param("id")
+	ctx := c.Request.Context()
+	cacheKey := fmt.Sprintf("%s%s?limit=%d&cursor=%s", cache.UserSmartListsKey(userId), smartListId, page.Size(), page.Cursor)
+	cacheValue, err := h.cache.Get(ctx, cacheKey)
+	if err == nil {
+		var response smartListItemsResponse
+		if err := json.Unmarshal([]byte(cacheValue), &response); err != nil {
+			newErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+		logrus.Debug("got from cache")
+		return
+	}
+
+	items, nextCursor, err := h.services.SmartList.GetItems(userId, smartListId, page)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	response := smartListItemsResponse{
+		Data:       items,
+		NextCursor: nextCursor,
+	}
+	h.cache.Set(ctx, cacheKey, response, smartListCacheTTL)
+
+	c.JSON(http.StatusOK, response)
+}
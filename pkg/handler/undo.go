@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// undoResponse - ответ на изменение, которое можно отменить. Токен пустой,
+// если сохранить его не удалось: само изменение при этом уже применено.
+type undoResponse struct {
+	Status    string `json:"status"`
+	UndoToken string `json:"undo_token,omitempty"`
+}
+
+func (h *Handler) undo(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		return
+	}
+
+	if err := h.services.Undo.Undo(userId, c.Param("token")); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{Status: "ok"})
+}
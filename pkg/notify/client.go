@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress - адрес получателя не публичный. Адреса вебхуков задают
+// пользователи, и без проверки через них можно обращаться к внутренним
+// сервисам.
+var ErrPrivateAddress = errors.New("destination address is not public")
+
+// blockedNets - диапазоны, которые не распознают методы net.IP, но снаружи
+// они недоступны так же, как частные сети.
+var blockedNets = mustParseCIDRs(
+	"0.0.0.0/8",     // "эта сеть" (RFC 1122), в Linux ведет на локальный хост
+	"100.64.0.0/10", // общее адресное пространство провайдеров (RFC 6598)
+	"198.18.0.0/15", // сети для тестов производительности (RFC 2544)
+	"64:ff9b::/96",  // NAT64 (RFC 6052): за префиксом может быть любой IPv4, в том числе внутренний
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = ipNet
+	}
+
+	return nets
+}
+
+// NewPublicClient возвращает HTTP-клиент, который соединяется только с
+// публичными адресами. Адрес проверяется после разрешения имени, поэтому
+// DNS-запись, указывающая на внутренний хост, тоже отклоняется. Редиректы
+// не выполняются: ответ 3xx возвращается как есть и считается неуспешным.
+func NewPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: publicAddressOnly,
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: &http.Transport{
+			// без прокси: иначе проверялся бы адрес прокси, а не получателя
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return ErrPrivateAddress
+	}
+
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() {
+		return false
+	}
+
+	for _, ipNet := range blockedNets {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
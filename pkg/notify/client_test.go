@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPublicClient(t *testing.T) {
+	var called bool
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer internal.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, internal.URL, nil)
+	_, err := NewPublicClient(time.Second).Do(req)
+
+	assert.ErrorIs(t, err, ErrPrivateAddress, "expected loopback address to be rejected")
+	assert.False(t, called, "expected internal server not to be reached")
+}
+
+func TestNewPublicClient_DoesNotFollowRedirects(t *testing.T) {
+	client := NewPublicClient(time.Second)
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/hook", nil)
+
+	assert.ErrorIs(t, client.CheckRedirect(req, []*http.Request{req}), http.ErrUseLastResponse,
+		"expected redirects not to be followed")
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"198.18.0.1", false},
+		{"198.19.255.254", false},
+		{"198.20.0.1", true},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b::a01:203", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.want, isPublicIP(net.ParseIP(tt.ip)), "unexpected result")
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+)
+
+// EmailNotifier кладет письмо в outbox, откуда его забирает почтовый отправитель.
+type EmailNotifier struct {
+	outbox repository.EmailOutbox
+}
+
+func NewEmailNotifier(outbox repository.EmailOutbox) *EmailNotifier {
+	return &EmailNotifier{outbox: outbox}
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, reminder todo.DueReminder) error {
+	body := fmt.Sprintf("Task %q", reminder.ItemTitle)
+	if reminder.ItemDueAt != nil {
+		body += fmt.Sprintf(" is due at %s", reminder.ItemDueAt.Format("2006-01-02 15:04 MST"))
+	}
+
+	_, err := n.outbox.Enqueue(reminder.Target, subject(reminder), body+".")
+
+	return err
+}
@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"context"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/sirupsen/logrus"
+)
+
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, reminder todo.DueReminder) error {
+	logrus.WithFields(logrus.Fields{
+		"reminder_id": reminder.Id,
+		"item_id":     reminder.ItemId,
+		"user_id":     reminder.UserId,
+		"fire_at":     reminder.FireAt,
+	}).Info(subject(reminder))
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	todo "github.com/balamuteon/todo_restapi"
+)
+
+// Notifier доставляет напоминание по одному каналу.
+type Notifier interface {
+	Notify(ctx context.Context, reminder todo.DueReminder) error
+}
+
+// Dispatcher выбирает Notifier по каналу напоминания.
+type Dispatcher map[string]Notifier
+
+func (d Dispatcher) Notify(ctx context.Context, reminder todo.DueReminder) error {
+	notifier, ok := d[reminder.Channel]
+	if !ok {
+		return fmt.Errorf("no notifier for channel %q", reminder.Channel)
+	}
+
+	return notifier.Notify(ctx, reminder)
+}
+
+func subject(reminder todo.DueReminder) string {
+	return fmt.Sprintf("Reminder: %s", reminder.ItemTitle)
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer отправляет письма из email_outbox через SMTP-сервер.
+type SMTPMailer struct {
+	cfg      SMTPConfig
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewSMTPMailer(cfg SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{cfg: cfg, sendMail: smtp.SendMail}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, email todo.Email) error {
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, m.cfg.Port)
+	return m.sendMail(addr, auth, m.cfg.From, []string{email.Recipient}, message(m.cfg.From, email))
+}
+
+// message собирает письмо в text/plain. Тема кодируется по RFC 2047: в нее
+// попадает название задачи, и перевод строки в нем не должен дописать
+// заголовки.
+func message(from string, email todo.Email) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", email.Recipient)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(email.Body)
+
+	return buf.Bytes()
+}
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPMailer_Send(t *testing.T) {
+	var addr, from string
+	var to []string
+	var msg []byte
+	mailer := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", Port: "587", From: "todo@example.com"})
+	mailer.sendMail = func(a string, _ smtp.Auth, f string, t []string, m []byte) error {
+		addr, from, to, msg = a, f, t, m
+		return nil
+	}
+
+	err := mailer.Send(context.Background(), todo.Email{
+		Recipient: "user@example.com",
+		Subject:   "Reminder: Купить молоко\r\nBcc: victim@example.com",
+		Body:      "Task is due.",
+	})
+
+	assert.NoError(t, err, "expected no error")
+	assert.Equal(t, "smtp.example.com:587", addr, "unexpected address")
+	assert.Equal(t, "todo@example.com", from, "unexpected sender")
+	assert.Equal(t, []string{"user@example.com"}, to, "unexpected recipients")
+
+	headers, body, _ := strings.Cut(string(msg), "\r\n\r\n")
+	assert.Equal(t, "Task is due.", body, "unexpected body")
+	assert.NotContains(t, headers, "\r\nBcc:", "expected subject not to inject headers")
+	assert.Contains(t, headers, "Subject: =?utf-8?q?", "expected encoded subject")
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSender_Send(t *testing.T) {
+	delivery := todo.DueWebhookDelivery{
+		WebhookDelivery: todo.WebhookDelivery{
+			Id:        7,
+			EventType: todo.EventItemCompleted,
+			Payload:   []byte(`{"type":"item.completed","list_id":1,"item_id":2}`),
+		},
+		Secret: "secret",
+	}
+
+	t.Run("signs body", func(t *testing.T) {
+		var received *http.Request
+		var body []byte
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		delivery := delivery
+		delivery.URL = receiver.URL
+		status, err := NewWebhookSender(receiver.Client()).Send(context.Background(), delivery)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, http.StatusNoContent, status, "unexpected status")
+
+		assert.Equal(t, string(delivery.Payload), string(body), "unexpected body")
+		assert.Equal(t, "7", received.Header.Get(WebhookDeliveryHeader), "unexpected delivery header")
+		assert.Equal(t, todo.EventItemCompleted, received.Header.Get(WebhookEventHeader), "unexpected event header")
+
+		// получатель проверяет подпись своим секретом
+		timestamp, err := strconv.ParseInt(received.Header.Get(WebhookTimestampHeader), 10, 64)
+		assert.NoError(t, err, "expected unix timestamp")
+		assert.Equal(t, Sign("secret", timestamp, body), received.Header.Get(WebhookSignatureHeader), "unexpected signature")
+		assert.NotEqual(t, Sign("other", timestamp, body), received.Header.Get(WebhookSignatureHeader), "expected signature to depend on secret")
+	})
+
+	t.Run("non-2xx response", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer receiver.Close()
+
+		delivery := delivery
+		delivery.URL = receiver.URL
+		status, err := NewWebhookSender(receiver.Client()).Send(context.Background(), delivery)
+		assert.Error(t, err, "expected error")
+		assert.Equal(t, http.StatusServiceUnavailable, status, "expected response status")
+	})
+
+	t.Run("unreachable receiver", func(t *testing.T) {
+		receiver := httptest.NewServer(http.NotFoundHandler())
+		receiver.Close()
+
+		delivery := delivery
+		delivery.URL = receiver.URL
+		status, err := NewWebhookSender(receiver.Client()).Send(context.Background(), delivery)
+		assert.Error(t, err, "expected error")
+		assert.Equal(t, 0, status, "expected no response status")
+	})
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163", Sign("secret", 1700000000, []byte("{}")))
+}
@@ -0,0 +1,222 @@
+// Package patch применяет к JSON-представлению ресурса JSON Merge Patch
+// (RFC 7396) и JSON Patch (RFC 6902). Ресурсы API плоские, поэтому JSON Patch
+// поддерживает только пути к полям верхнего уровня.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// Document - JSON-объект ресурса. Явный null хранится как nil, удаленное
+// поле отсутствует в map.
+type Document map[string]interface{}
+
+var (
+	// ErrUnsupportedType - тип содержимого не является форматом патча.
+	ErrUnsupportedType = errors.New("unsupported patch content type")
+	// ErrTestFailed - не прошла операция test из JSON Patch.
+	ErrTestFailed = errors.New("patch test operation failed")
+)
+
+// Error - ошибка в теле патча.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func errorf(format string, args ...interface{}) error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// NewDocument переводит ресурс в Document через его JSON-представление.
+func NewDocument(v interface{}) (Document, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Apply применяет патч формата contentType к копии doc.
+func Apply(contentType string, doc Document, patch []byte) (Document, error) {
+	switch contentType {
+	case MergePatchType:
+		return applyMergePatch(doc, patch)
+	case JSONPatchType:
+		return applyJSONPatch(doc, patch)
+	default:
+		return nil, ErrUnsupportedType
+	}
+}
+
+func applyMergePatch(doc Document, patch []byte) (Document, error) {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, errorf("malformed merge patch: %s", err.Error())
+	}
+
+	obj, ok := p.(map[string]interface{})
+	if !ok {
+		return nil, errorf("merge patch must be a JSON object")
+	}
+
+	return Document(mergePatch(map[string]interface{}(doc.clone()), obj).(map[string]interface{})), nil
+}
+
+// mergePatch - алгоритм из раздела 2 RFC 7396: null удаляет поле, объекты
+// сливаются рекурсивно, остальные значения заменяются целиком.
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergePatch(t[key], value)
+		}
+	}
+
+	return t
+}
+
+type operation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+func applyJSONPatch(doc Document, patch []byte) (Document, error) {
+	var ops []operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, errorf("json patch must be an array of operations: %s", err.Error())
+	}
+
+	result := doc.clone()
+	for i, op := range ops {
+		if err := result.apply(op); err != nil {
+			var patchErr *Error
+			if errors.As(err, &patchErr) {
+				return nil, errorf("operation %d: %s", i, patchErr.Message)
+			}
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return result, nil
+}
+
+func (d Document) apply(op operation) error {
+	if op.Path == nil {
+		return errorf("missing path")
+	}
+
+	key, err := field(*op.Path)
+	if err != nil {
+		return err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		value, err := op.value()
+		if err != nil {
+			return err
+		}
+
+		current, exists := d[key]
+		switch {
+		case op.Op == "add":
+			d[key] = value
+		case !exists:
+			return errorf("path %q does not exist", *op.Path)
+		case op.Op == "replace":
+			d[key] = value
+		case !reflect.DeepEqual(current, value):
+			return fmt.Errorf("%w: %s", ErrTestFailed, *op.Path)
+		}
+	case "remove":
+		if _, exists := d[key]; !exists {
+			return errorf("path %q does not exist", *op.Path)
+		}
+		delete(d, key)
+	case "move", "copy":
+		if op.From == nil {
+			return errorf("missing from")
+		}
+
+		from, err := field(*op.From)
+		if err != nil {
+			return err
+		}
+
+		value, exists := d[from]
+		if !exists {
+			return errorf("path %q does not exist", *op.From)
+		}
+		if op.Op == "move" {
+			delete(d, from)
+		}
+		d[key] = value
+	default:
+		return errorf("unknown operation %q", op.Op)
+	}
+
+	return nil
+}
+
+func (op operation) value() (interface{}, error) {
+	if op.Value == nil {
+		return nil, errorf("missing value")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(*op.Value, &value); err != nil {
+		return nil, errorf("malformed value: %s", err.Error())
+	}
+
+	return value, nil
+}
+
+// field разбирает JSON Pointer (RFC 6901) вида /name.
+func field(pointer string) (string, error) {
+	if !strings.HasPrefix(pointer, "/") || strings.Count(pointer, "/") != 1 {
+		return "", errorf("path %q is not supported: only top-level fields can be patched", pointer)
+	}
+
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(pointer[1:]), nil
+}
+
+func (d Document) clone() Document {
+	c := make(Document, len(d))
+	for key, value := range d {
+		c[key] = value
+	}
+
+	return c
+}
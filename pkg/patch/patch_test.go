@@ -0,0 +1,102 @@
+package patch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func document() Document {
+	return Document{"id": float64(1), "title": "milk", "description": "2 l", "due_at": "2024-05-10T00:00:00Z"}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		want        Document
+	}{
+		{
+			name:        "merge patch sets and removes",
+			contentType: MergePatchType,
+			patch:       `{"title": "bread", "due_at": null}`,
+			want:        Document{"id": float64(1), "title": "bread", "description": "2 l"},
+		},
+		{
+			name:        "merge patch adds field",
+			contentType: MergePatchType,
+			patch:       `{"done": true}`,
+			want:        Document{"id": float64(1), "title": "milk", "description": "2 l", "due_at": "2024-05-10T00:00:00Z", "done": true},
+		},
+		{
+			name:        "json patch operations",
+			contentType: JSONPatchType,
+			patch: `[
+				{"op": "test", "path": "/title", "value": "milk"},
+				{"op": "replace", "path": "/title", "value": "bread"},
+				{"op": "remove", "path": "/due_at"},
+				{"op": "copy", "from": "/title", "path": "/description"}
+			]`,
+			want: Document{"id": float64(1), "title": "bread", "description": "bread"},
+		},
+		{
+			name:        "json patch escaped path",
+			contentType: JSONPatchType,
+			patch:       `[{"op": "add", "path": "/a~1b~0c", "value": 1}]`,
+			want:        Document{"id": float64(1), "title": "milk", "description": "2 l", "due_at": "2024-05-10T00:00:00Z", "a/b~c": float64(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := document()
+			got, err := Apply(tt.contentType, doc, []byte(tt.patch))
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, tt.want, got, "unexpected document")
+			assert.Equal(t, document(), doc, "expected original document to stay unchanged")
+		})
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		want        error
+	}{
+		{name: "unsupported type", contentType: "application/json", patch: `{}`, want: ErrUnsupportedType},
+		{name: "test failed", contentType: JSONPatchType, patch: `[{"op": "test", "path": "/title", "value": "bread"}]`, want: ErrTestFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply(tt.contentType, document(), []byte(tt.patch))
+			assert.ErrorIs(t, err, tt.want, "unexpected error")
+		})
+	}
+
+	invalid := []struct {
+		name        string
+		contentType string
+		patch       string
+	}{
+		{name: "merge patch not an object", contentType: MergePatchType, patch: `[1]`},
+		{name: "json patch not an array", contentType: JSONPatchType, patch: `{"op": "add"}`},
+		{name: "nested path", contentType: JSONPatchType, patch: `[{"op": "add", "path": "/a/b", "value": 1}]`},
+		{name: "replace missing field", contentType: JSONPatchType, patch: `[{"op": "replace", "path": "/done", "value": true}]`},
+		{name: "missing value", contentType: JSONPatchType, patch: `[{"op": "add", "path": "/done"}]`},
+		{name: "unknown operation", contentType: JSONPatchType, patch: `[{"op": "merge", "path": "/done"}]`},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply(tt.contentType, document(), []byte(tt.patch))
+
+			var patchErr *Error
+			assert.True(t, errors.As(err, &patchErr), "expected patch error, got %v", err)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package query
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// relativeDate - смещение от текущего момента: 3d, -2w, +1m, 12h.
+var relativeDate = regexp.MustCompile(`^([+-]?)(\d{1,4})([hdwm])$`)
+
+// resolveDate переводит значение даты в полуинтервал [from, to). Дни (today,
+// 2024-05-01, +3d) занимают сутки целиком, моменты (now, 12h, RFC 3339) - одну
+// микросекунду, точность timestamptz в Postgres.
+func resolveDate(value string, now time.Time) (time.Time, time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch strings.ToLower(value) {
+	case "now":
+		return instant(now)
+	case "today":
+		return day(today)
+	case "tomorrow":
+		return day(today.AddDate(0, 0, 1))
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1))
+	}
+
+	if m := relativeDate.FindStringSubmatch(strings.ToLower(value)); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "-" {
+			n = -n
+		}
+
+		switch m[3] {
+		case "h":
+			return instant(now.Add(time.Duration(n) * time.Hour))
+		case "d":
+			return day(today.AddDate(0, 0, n))
+		case "w":
+			return day(today.AddDate(0, 0, 7*n))
+		case "m":
+			return day(today.AddDate(0, n, 0))
+		}
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return day(t)
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return instant(t)
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+func day(start time.Time) (time.Time, time.Time, bool) {
+	return start, start.AddDate(0, 0, 1), true
+}
+
+func instant(t time.Time) (time.Time, time.Time, bool) {
+	t = t.Truncate(time.Microsecond)
+
+	return t, t.Add(time.Microsecond), true
+}
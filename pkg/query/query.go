@@ -0,0 +1,460 @@
+// Package query разбирает компактный язык фильтров задач, например
+// `done:false due<tomorrow tag:work (title:"отчет" OR assignee:me) -молоко`.
+//
+// Термы без поля ищутся в названии и описании. Соседние термы объединяются
+// через AND; также поддерживаются AND, OR, NOT (только заглавными), отрицание
+// через "-" и скобки. Поля: done, due, title, description, assignee, tag,
+// priority.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	maxLength = 500
+	maxDepth  = 32
+)
+
+// Node - узел разобранного выражения.
+type Node interface {
+	node()
+}
+
+type And struct {
+	Left, Right Node
+}
+
+type Or struct {
+	Left, Right Node
+}
+
+type Not struct {
+	Node Node
+}
+
+type Done struct {
+	Value bool
+}
+
+// Due ограничивает срок полуинтервалом [From, To); nil граница не ограничивает.
+// None выбирает задачи без срока.
+type Due struct {
+	From, To *time.Time
+	None     bool
+}
+
+const (
+	TextAny         = ""
+	TextTitle       = "title"
+	TextDescription = "description"
+)
+
+// Text - поиск подстроки без учета регистра; Field пустой - в названии или описании.
+type Text struct {
+	Field string
+	Value string
+}
+
+// Assignee выбирает задачи текущего пользователя (Me), без исполнителя (None)
+// или с исполнителем UserId.
+type Assignee struct {
+	Me     bool
+	None   bool
+	UserId int
+}
+
+// Tag выбирает задачи с тегом Value.
+type Tag struct {
+	Value string
+}
+
+// Priority сравнивает приоритет задачи с Value; Op - одно из =, <, <=, >, >=.
+type Priority struct {
+	Op    string
+	Value int
+}
+
+// priorityNames - названия приоритетов по возрастанию, индекс - уровень, как
+// в todo.PriorityNone..todo.PriorityHigh.
+var priorityNames = []string{"none", "low", "medium", "high"}
+
+func (And) node()      {}
+func (Or) node()       {}
+func (Not) node()      {}
+func (Done) node()     {}
+func (Due) node()      {}
+func (Text) node()     {}
+func (Assignee) node() {}
+func (Tag) node()      {}
+func (Priority) node() {}
+
+// Error - ошибка разбора. Offset - смещение в символах (не байтах) от начала
+// запроса, начиная с нуля.
+type Error struct {
+	Offset  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Offset)
+}
+
+// Parse разбирает запрос. Относительные даты (today, tomorrow, +3d) считаются
+// от now в его часовом поясе.
+func Parse(input string, now time.Time) (Node, error) {
+	p := &parser{src: []rune(input), now: now}
+
+	if len(p.src) > maxLength {
+		return nil, p.errorf(maxLength, "query is longer than %d characters", maxLength)
+	}
+
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf(p.pos, "query is empty")
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected %q", p.src[p.pos])
+	}
+
+	return node, nil
+}
+
+type parser struct {
+	src   []rune
+	pos   int
+	depth int
+	now   time.Time
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skipSpace()
+		if !p.keyword("OR") {
+			return left, nil
+		}
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skipSpace()
+		if p.eof() || p.peek() == ')' || p.atKeyword("OR") {
+			return left, nil
+		}
+
+		// AND необязателен: соседние термы и так объединяются
+		p.keyword("AND")
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.errorf(p.pos, "query is nested deeper than %d levels", maxDepth)
+	}
+
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf(p.pos, "expected term")
+	}
+
+	if p.keyword("NOT") {
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: node}, nil
+	}
+
+	if p.peek() == '-' {
+		start := p.pos
+		p.pos++
+		if p.eof() || unicode.IsSpace(p.peek()) {
+			return nil, p.errorf(start, "expected term after \"-\"")
+		}
+
+		node, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: node}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	switch p.peek() {
+	case '(':
+		open := p.pos
+		p.pos++
+
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if p.eof() || p.peek() != ')' {
+			return nil, p.errorf(open, "unclosed parenthesis")
+		}
+		p.pos++
+
+		return node, nil
+	case '"':
+		value, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return Text{Value: value}, nil
+	}
+
+	start := p.pos
+	word := p.scanWhile(isWordRune)
+	if word == "" {
+		return nil, p.errorf(start, "unexpected %q", p.peek())
+	}
+
+	if p.eof() || !isOpRune(p.peek()) {
+		return Text{Value: word}, nil
+	}
+
+	opPos := p.pos
+	op := p.scanWhile(isOpRune)
+
+	valuePos := p.pos
+	var value string
+	if !p.eof() && p.peek() == '"' {
+		var err error
+		if value, err = p.parseString(); err != nil {
+			return nil, err
+		}
+	} else {
+		// значение может содержать ":" - например, время в RFC 3339
+		value = p.scanWhile(func(r rune) bool { return !unicode.IsSpace(r) && r != '(' && r != ')' })
+		if value == "" {
+			return nil, p.errorf(valuePos, "expected value after %s%s", word, op)
+		}
+	}
+
+	return p.predicate(word, start, op, opPos, value, valuePos)
+}
+
+func (p *parser) predicate(field string, fieldPos int, op string, opPos int, value string, valuePos int) (Node, error) {
+	switch field = strings.ToLower(field); field {
+	case "done":
+		if err := p.equalityOnly(field, op, opPos); err != nil {
+			return nil, err
+		}
+
+		done, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, p.errorf(valuePos, "done expects true or false, got %q", value)
+		}
+		return Done{Value: done}, nil
+	case "due":
+		return p.due(op, opPos, value, valuePos)
+	case TextTitle, TextDescription:
+		if err := p.equalityOnly(field, op, opPos); err != nil {
+			return nil, err
+		}
+		return Text{Field: field, Value: value}, nil
+	case "assignee":
+		if err := p.equalityOnly(field, op, opPos); err != nil {
+			return nil, err
+		}
+
+		switch strings.ToLower(value) {
+		case "me":
+			return Assignee{Me: true}, nil
+		case "none":
+			return Assignee{None: true}, nil
+		}
+
+		userId, err := strconv.Atoi(value)
+		if err != nil || userId <= 0 {
+			return nil, p.errorf(valuePos, "assignee expects me, none or user id, got %q", value)
+		}
+		return Assignee{UserId: userId}, nil
+	case "tag":
+		if err := p.equalityOnly(field, op, opPos); err != nil {
+			return nil, err
+		}
+		return Tag{Value: value}, nil
+	case "priority":
+		return p.priority(op, opPos, value, valuePos)
+	default:
+		return nil, p.errorf(fieldPos, "unknown field %q", field)
+	}
+}
+
+func (p *parser) due(op string, opPos int, value string, valuePos int) (Node, error) {
+	if strings.EqualFold(value, "none") {
+		if err := p.equalityOnly("due", op, opPos); err != nil {
+			return nil, err
+		}
+		return Due{None: true}, nil
+	}
+
+	from, to, ok := resolveDate(value, p.now)
+	if !ok {
+		return nil, p.errorf(valuePos, "invalid date %q", value)
+	}
+
+	switch op {
+	case ":", "=":
+		return Due{From: &from, To: &to}, nil
+	case "<":
+		return Due{To: &from}, nil
+	case "<=":
+		return Due{To: &to}, nil
+	case ">":
+		return Due{From: &to}, nil
+	case ">=":
+		return Due{From: &from}, nil
+	default:
+		return nil, p.errorf(opPos, "unknown operator %q", op)
+	}
+}
+
+func (p *parser) priority(op string, opPos int, value string, valuePos int) (Node, error) {
+	level := -1
+	for i, name := range priorityNames {
+		if strings.EqualFold(value, name) || value == strconv.Itoa(i) {
+			level = i
+		}
+	}
+	if level < 0 {
+		return nil, p.errorf(valuePos, "priority expects %s or 0-%d, got %q",
+			strings.Join(priorityNames, ", "), len(priorityNames)-1, value)
+	}
+
+	switch op {
+	case ":", "=":
+		return Priority{Op: "=", Value: level}, nil
+	case "<", "<=", ">", ">=":
+		return Priority{Op: op, Value: level}, nil
+	default:
+		return nil, p.errorf(opPos, "unknown operator %q", op)
+	}
+}
+
+func (p *parser) equalityOnly(field, op string, opPos int) error {
+	if op != ":" && op != "=" {
+		return p.errorf(opPos, "operator %q is not supported for %s", op, field)
+	}
+
+	return nil
+}
+
+func (p *parser) parseString() (string, error) {
+	open := p.pos
+	p.pos++
+
+	var b strings.Builder
+	for !p.eof() {
+		r := p.src[p.pos]
+		p.pos++
+
+		switch r {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", p.errorf(open, "unterminated string")
+			}
+			r = p.src[p.pos]
+			p.pos++
+		}
+		b.WriteRune(r)
+	}
+
+	return "", p.errorf(open, "unterminated string")
+}
+
+// keyword съедает ключевое слово, если оно стоит отдельным словом.
+func (p *parser) keyword(kw string) bool {
+	if !p.atKeyword(kw) {
+		return false
+	}
+	p.pos += len(kw)
+
+	return true
+}
+
+func (p *parser) atKeyword(kw string) bool {
+	end := p.pos + len(kw)
+	if end > len(p.src) || string(p.src[p.pos:end]) != kw {
+		return false
+	}
+
+	return end == len(p.src) || unicode.IsSpace(p.src[end]) || p.src[end] == '(' || p.src[end] == '"'
+}
+
+func (p *parser) scanWhile(f func(rune) bool) string {
+	start := p.pos
+	for !p.eof() && f(p.src[p.pos]) {
+		p.pos++
+	}
+
+	return string(p.src[start:p.pos])
+}
+
+func (p *parser) skipSpace() {
+	p.scanWhile(unicode.IsSpace)
+}
+
+func (p *parser) peek() rune {
+	return p.src[p.pos]
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) errorf(offset int, format string, args ...interface{}) error {
+	return &Error{Offset: offset, Message: fmt.Sprintf(format, args...)}
+}
+
+func isWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && !isOpRune(r) && r != '(' && r != ')' && r != '"'
+}
+
+func isOpRune(r rune) bool {
+	return r == ':' || r == '<' || r == '>' || r == '='
+}
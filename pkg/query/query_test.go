@@ -0,0 +1,119 @@
+package query
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2024, 5, 10, 15, 30, 0, 0, time.UTC)
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Node
+	}{
+		{
+			name:  "implicit and",
+			input: "done:false due<tomorrow",
+			want:  And{Left: Done{Value: false}, Right: Due{To: date(2024, 5, 11)}},
+		},
+		{
+			name:  "or binds weaker than and",
+			input: "a b OR c",
+			want:  Or{Left: And{Left: Text{Value: "a"}, Right: Text{Value: "b"}}, Right: Text{Value: "c"}},
+		},
+		{
+			name:  "parentheses and negation",
+			input: `-(title:"weekly report" OR assignee:me) NOT done:true`,
+			want: And{
+				Left:  Not{Node: Or{Left: Text{Field: TextTitle, Value: "weekly report"}, Right: Assignee{Me: true}}},
+				Right: Not{Node: Done{Value: true}},
+			},
+		},
+		{
+			name:  "due day and range operators",
+			input: "due:today due>=2024-05-01 due<=+1w",
+			want: And{
+				Left:  And{Left: Due{From: date(2024, 5, 10), To: date(2024, 5, 11)}, Right: Due{From: date(2024, 5, 1)}},
+				Right: Due{To: date(2024, 5, 18)},
+			},
+		},
+		{
+			name:  "keywords are case sensitive",
+			input: "cats or dogs",
+			want:  And{Left: And{Left: Text{Value: "cats"}, Right: Text{Value: "or"}}, Right: Text{Value: "dogs"}},
+		},
+		{
+			name:  "tag and priority",
+			input: "done:false due<tomorrow tag:work",
+			want: And{
+				Left:  And{Left: Done{Value: false}, Right: Due{To: date(2024, 5, 11)}},
+				Right: Tag{Value: "work"},
+			},
+		},
+		{
+			name:  "priority by name and level",
+			input: "priority>=medium priority:0 -priority:HIGH",
+			want: And{
+				Left:  And{Left: Priority{Op: ">=", Value: 2}, Right: Priority{Op: "=", Value: 0}},
+				Right: Not{Node: Priority{Op: "=", Value: 3}},
+			},
+		},
+		{
+			name:  "due none and unassigned",
+			input: "due:none assignee:none",
+			want:  And{Left: Due{None: true}, Right: Assignee{None: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input, now)
+			assert.NoError(t, err, "expected no error")
+			assert.Equal(t, tt.want, got, "unexpected tree")
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		offset int
+	}{
+		{name: "empty", input: "   ", offset: 3},
+		{name: "unknown field", input: "done:true label:work", offset: 10},
+		{name: "bad priority", input: "priority:urgent", offset: 9},
+		{name: "tag equality only", input: "tag>work", offset: 3},
+		{name: "bad bool", input: "done:maybe", offset: 5},
+		{name: "bad date", input: "due<someday", offset: 4},
+		{name: "unsupported operator", input: "title<abc", offset: 5},
+		{name: "unclosed parenthesis", input: "a (b OR c", offset: 2},
+		{name: "stray parenthesis", input: "a b)", offset: 3},
+		{name: "dangling or", input: "a OR", offset: 4},
+		{name: "missing value", input: "due: x", offset: 4},
+		{name: "unterminated string", input: `title:"abc`, offset: 6},
+		{name: "offset counts characters", input: "молоко done:нет", offset: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input, now)
+
+			var queryErr *Error
+			assert.True(t, errors.As(err, &queryErr), "expected parse error, got %v", err)
+			if queryErr != nil {
+				assert.Equal(t, tt.offset, queryErr.Offset, "unexpected offset: %s", queryErr.Message)
+			}
+		})
+	}
+}
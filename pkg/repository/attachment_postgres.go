@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"fmt"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+)
+
+type AttachmentPostgres struct {
+	db *sqlx.DB
+}
+
+func NewAttachmentPostgres(db *sqlx.DB) *AttachmentPostgres {
+	return &AttachmentPostgres{db: db}
+}
+
+func (r *AttachmentPostgres) Create(attachment todo.Attachment) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (item_id, uploader_id, filename, content_type, size, checksum, storage_key)
+												VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`, attachmentsTable)
+	row := r.db.QueryRow(query, attachment.ItemId, attachment.UploaderId, attachment.Filename,
+		attachment.ContentType, attachment.Size, attachment.Checksum, attachment.StorageKey)
+	if err := row.Scan(&id); err != nil {
+		return 0, dbError(err, "item")
+	}
+
+	return id, nil
+}
+
+func (r *AttachmentPostgres) GetAll(userId, itemId int) ([]todo.Attachment, error) {
+	attachments := make([]todo.Attachment, 0)
+	query := fmt.Sprintf(`SELECT a.id, a.item_id, a.uploader_id, a.filename, a.content_type, a.size, a.checksum, a.storage_key, a.created_at
+												FROM %s a
+												JOIN %s li ON li.item_id = a.item_id
+												JOIN %s ul ON ul.list_id = li.list_id
+												WHERE a.item_id = $1 AND ul.user_id = $2
+												ORDER BY a.id`,
+		attachmentsTable, listsItemsTable, usersListsTable)
+	err := r.db.Select(&attachments, query, itemId, userId)
+
+	return attachments, err
+}
+
+func (r *AttachmentPostgres) GetById(userId, itemId, attachmentId int) (todo.Attachment, error) {
+	var attachment todo.Attachment
+	query := fmt.Sprintf(`SELECT a.id, a.item_id, a.uploader_id, a.filename, a.content_type, a.size, a.checksum, a.storage_key, a.created_at
+												FROM %s a
+												JOIN %s li ON li.item_id = a.item_id
+												JOIN %s ul ON ul.list_id = li.list_id
+												WHERE a.id = $1 AND a.item_id = $2 AND ul.user_id = $3`,
+		attachmentsTable, listsItemsTable, usersListsTable)
+	err := r.db.Get(&attachment, query, attachmentId, itemId, userId)
+
+	return attachment, dbError(err, "attachment")
+}
+
+// Delete удаляет запись и возвращает ключ блоба, который нужно убрать из хранилища.
+func (r *AttachmentPostgres) Delete(userId, itemId, attachmentId int) (string, error) {
+	var storageKey string
+	query := fmt.Sprintf(`DELETE FROM %s a USING %s li, %s ul
+												WHERE a.item_id = li.item_id AND li.list_id = ul.list_id
+													AND a.id = $1 AND a.item_id = $2 AND ul.user_id = $3
+												RETURNING a.storage_key`,
+		attachmentsTable, listsItemsTable, usersListsTable)
+	err := r.db.Get(&storageKey, query, attachmentId, itemId, userId)
+
+	return storageKey, dbError(err, "attachment")
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentPostgres_Create(t *testing.T) {
+	t.Run("create, get and delete attachment", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewAttachmentPostgres(db)
+		attachment := todo.Attachment{
+			ItemId:      itemId,
+			UploaderId:  userId,
+			Filename:    "receipt.pdf",
+			ContentType: "application/pdf",
+			Size:        42,
+			Checksum:    "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+			StorageKey:  "0123456789abcdef",
+		}
+		id, err := repo.Create(attachment)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, 1, id, "expected attachment ID=1")
+
+		dbAttachment, err := repo.GetById(userId, itemId, id)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, attachment.Checksum, dbAttachment.Checksum, "expected checksum to match")
+		assert.Equal(t, attachment.StorageKey, dbAttachment.StorageKey, "expected storage key to match")
+
+		// не участник списка не получает вложение
+		_, err = repo.GetById(999, itemId, id)
+		assert.Error(t, err, "expected error for non-member")
+
+		attachments, err := repo.GetAll(userId, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, attachments, 1, "expected one attachment")
+
+		key, err := repo.Delete(userId, itemId, id)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, attachment.StorageKey, key, "expected storage key of deleted attachment")
+
+		_, err = repo.GetById(userId, itemId, id)
+		assert.Error(t, err, "expected attachment to be deleted")
+	})
+}
@@ -20,7 +20,7 @@ func (r *AuthPostgres) CreateUser(user todo.User) (int, error) {
 	query := fmt.Sprintf("INSERT INTO %s (name, username, password_hash) VALUES ($1, $2, $3) RETURNING id", usersTable)
 	row := r.db.QueryRow(query, user.Name, user.Username, user.Password)
 	if err := row.Scan(&id); err != nil {
-		return 0, err
+		return 0, dbError(err, "user")
 	}
 
 	return id, nil
@@ -31,5 +31,5 @@ func (r *AuthPostgres) GetUser(username, password string) (todo.User, error) {
 	query := fmt.Sprintf("SELECT id, name, username, password_hash FROM %s WHERE username=$1 AND password_hash=$2", usersTable)
 	err := r.db.Get(&user, query, username, password)
 
-	return user, err
+	return user, dbError(err, "user")
 }
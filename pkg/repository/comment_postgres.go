@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+)
+
+type CommentPostgres struct {
+	db *sqlx.DB
+}
+
+func NewCommentPostgres(db *sqlx.DB) *CommentPostgres {
+	return &CommentPostgres{db: db}
+}
+
+func (r *CommentPostgres) Create(userId, itemId int, comment todo.Comment) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (item_id, author_id, body) VALUES ($1, $2, $3) RETURNING id", commentsTable)
+	row := r.db.QueryRow(query, itemId, userId, comment.Body)
+	if err := row.Scan(&id); err != nil {
+		return 0, dbError(err, "item")
+	}
+
+	return id, nil
+}
+
+func (r *CommentPostgres) GetAll(userId, itemId int) ([]todo.Comment, error) {
+	comments := make([]todo.Comment, 0)
+	query := fmt.Sprintf(`SELECT c.id, c.item_id, c.author_id, u.name AS author_name, c.body, c.edited, c.created_at, c.updated_at
+												FROM %s c
+												JOIN %s u ON u.id = c.author_id
+												JOIN %s li ON li.item_id = c.item_id
+												JOIN %s ul ON ul.list_id = li.list_id
+												WHERE c.item_id = $1 AND ul.user_id = $2
+												ORDER BY c.created_at, c.id`,
+		commentsTable, usersTable, listsItemsTable, usersListsTable)
+	err := r.db.Select(&comments, query, itemId, userId)
+
+	return comments, err
+}
+
+func (r *CommentPostgres) Update(userId, itemId, commentId int, input todo.UpdateCommentInput) error {
+	query := fmt.Sprintf(`UPDATE %s SET body = $1, edited = true, updated_at = now()
+												WHERE id = $2 AND item_id = $3 AND author_id = $4`, commentsTable)
+	err := execAffected(r.db, "comment", query, *input.Body, commentId, itemId, userId)
+
+	return r.authorError(err, itemId, commentId)
+}
+
+func (r *CommentPostgres) Delete(userId, itemId, commentId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND item_id = $2 AND author_id = $3", commentsTable)
+	err := execAffected(r.db, "comment", query, commentId, itemId, userId)
+
+	return r.authorError(err, itemId, commentId)
+}
+
+// authorError уточняет ErrNotFound после изменения: если комментарий есть, но
+// написан другим участником, это ErrForbidden.
+func (r *CommentPostgres) authorError(err error, itemId, commentId int) error {
+	if !errors.Is(err, todo.ErrNotFound) {
+		return err
+	}
+
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND item_id = $2)", commentsTable)
+	if err := r.db.Get(&exists, query, commentId, itemId); err != nil {
+		return err
+	}
+
+	if exists {
+		return todo.NewError(todo.ErrForbidden, "only the author can change a comment")
+	}
+
+	return err
+}
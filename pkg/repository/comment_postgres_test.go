@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentPostgres_Create(t *testing.T) {
+	t.Run("create and get comments", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewCommentPostgres(db)
+		id, err := repo.Create(userId, itemId, todo.Comment{Body: "first"})
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, 1, id, "expected comment ID=1")
+
+		comments, err := repo.GetAll(userId, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, comments, 1, "expected one comment")
+		assert.Equal(t, "first", comments[0].Body, "expected comment body")
+		assert.Equal(t, "John Doe", comments[0].AuthorName, "expected author name")
+		assert.False(t, comments[0].Edited, "expected comment not to be edited")
+
+		// не участник списка не видит комментарии
+		comments, err = repo.GetAll(999, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, comments, "expected no comments for non-member")
+	})
+}
+
+func TestCommentPostgres_Update(t *testing.T) {
+	t.Run("author edits comment", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewCommentPostgres(db)
+		id, err := repo.Create(userId, itemId, todo.Comment{Body: "first"})
+		assert.NoError(t, err, "expected no error")
+
+		body := "edited"
+		err = repo.Update(userId, itemId, id, todo.UpdateCommentInput{Body: &body})
+		assert.NoError(t, err, "expected no error")
+
+		comments, err := repo.GetAll(userId, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, body, comments[0].Body, "expected body to be updated")
+		assert.True(t, comments[0].Edited, "expected edited flag to be set")
+	})
+}
+
+func TestCommentPostgres_Delete(t *testing.T) {
+	t.Run("comments removed with item", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewCommentPostgres(db)
+		_, err = repo.Create(userId, itemId, todo.Comment{Body: "first"})
+		assert.NoError(t, err, "expected no error")
+
+		assert.NoError(t, todoItemRepo.Delete(userId, itemId, nil), "failed to delete item")
+
+		var count int
+		err = db.Get(&count, "SELECT COUNT(*) FROM comments WHERE item_id=$1", itemId)
+		assert.NoError(t, err, "failed to count comments")
+		assert.Equal(t, 0, count, "expected comments to be deleted with item")
+	})
+}
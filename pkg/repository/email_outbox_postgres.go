@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+)
+
+type EmailOutboxPostgres struct {
+	db *sqlx.DB
+}
+
+func NewEmailOutboxPostgres(db *sqlx.DB) *EmailOutboxPostgres {
+	return &EmailOutboxPostgres{db: db}
+}
+
+func (r *EmailOutboxPostgres) Enqueue(recipient, subject, body string) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (recipient, subject, body) VALUES ($1, $2, $3) RETURNING id", emailOutboxTable)
+	row := r.db.QueryRow(query, recipient, subject, body)
+	if err := row.Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ClaimDue захватывает письма к отправке так же, как ReminderPostgres.ClaimDue.
+func (r *EmailOutboxPostgres) ClaimDue(limit int, lease time.Duration) ([]todo.Email, error) {
+	var emails []todo.Email
+	query := fmt.Sprintf(`WITH due AS (
+													SELECT id FROM %[1]s
+													WHERE status = $1 AND next_attempt_at <= now()
+													ORDER BY next_attempt_at
+													LIMIT $2
+													FOR UPDATE SKIP LOCKED
+												)
+												UPDATE %[1]s e SET attempts = e.attempts + 1, next_attempt_at = now() + $3::float8 * interval '1 second'
+												FROM due WHERE e.id = due.id
+												RETURNING e.id, e.recipient, e.subject, e.body, e.attempts`,
+		emailOutboxTable)
+	err := r.db.Select(&emails, query, todo.ReminderStatusPending, limit, lease.Seconds())
+
+	return emails, err
+}
+
+func (r *EmailOutboxPostgres) MarkSent(emailId int) error {
+	query := fmt.Sprintf("UPDATE %s SET status = $1, sent_at = now(), last_error = NULL WHERE id = $2", emailOutboxTable)
+	_, err := r.db.Exec(query, todo.ReminderStatusSent, emailId)
+
+	return err
+}
+
+func (r *EmailOutboxPostgres) MarkRetry(emailId int, nextAttemptAt time.Time, lastErr string) error {
+	query := fmt.Sprintf("UPDATE %s SET next_attempt_at = $1, last_error = $2 WHERE id = $3", emailOutboxTable)
+	_, err := r.db.Exec(query, nextAttemptAt, lastErr, emailId)
+
+	return err
+}
+
+func (r *EmailOutboxPostgres) MarkFailed(emailId int, lastErr string) error {
+	query := fmt.Sprintf("UPDATE %s SET status = $1, last_error = $2 WHERE id = $3", emailOutboxTable)
+	_, err := r.db.Exec(query, todo.ReminderStatusFailed, lastErr, emailId)
+
+	return err
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailOutboxPostgres_ClaimDue(t *testing.T) {
+	t.Run("claims emails once and retries failed ones", func(t *testing.T) {
+		db, _, _, _, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE email_outbox RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		repo := NewEmailOutboxPostgres(db)
+		id, err := repo.Enqueue("user@example.com", "Reminder: Test", "Task is due.")
+		assert.NoError(t, err, "expected no error")
+
+		emails, err := repo.ClaimDue(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, emails, 1, "expected one email")
+		assert.Equal(t, "user@example.com", emails[0].Recipient, "unexpected recipient")
+		assert.Equal(t, 1, emails[0].Attempts, "expected attempts to be incremented")
+
+		// повторный захват в пределах lease ничего не возвращает
+		emails, err = repo.ClaimDue(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, emails, "expected email to stay claimed")
+
+		assert.NoError(t, repo.MarkRetry(id, time.Now().Add(-time.Second), "connection refused"), "expected no error")
+		emails, err = repo.ClaimDue(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, emails, 1, "expected email to be retried")
+
+		assert.NoError(t, repo.MarkSent(id), "expected no error")
+		assert.NoError(t, repo.MarkRetry(id, time.Now().Add(-time.Second), ""), "expected no error")
+		emails, err = repo.ClaimDue(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, emails, "expected sent email not to be claimed")
+	})
+}
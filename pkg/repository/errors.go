@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// dbError переводит ошибки БД в доменные: отсутствие строки и ссылку на
+// несуществующую запись - в ErrNotFound, нарушение уникальности - в ErrConflict.
+// Остальные ошибки возвращаются как есть.
+func dbError(err error, entity string) error {
+	var pqErr *pq.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return todo.NewError(todo.ErrNotFound, "%s not found", entity)
+	case errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation":
+		return todo.NewError(todo.ErrConflict, "%s already exists", entity)
+	case errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation":
+		return todo.NewError(todo.ErrNotFound, "%s refers to a missing record", entity)
+	default:
+		return err
+	}
+}
+
+// execAffected выполняет изменение и возвращает ErrNotFound, если оно не
+// затронуло ни одной строки: записи нет или она недоступна пользователю.
+func execAffected(db sqlx.Execer, entity, query string, args ...interface{}) error {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return dbError(err, entity)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return todo.NewError(todo.ErrNotFound, "%s not found", entity)
+	}
+
+	return nil
+}
+
+// versionError уточняет ErrNotFound от изменения с проверкой версии: если
+// запись по-прежнему доступна, значит не совпала версия.
+func versionError(err error, entity string, version *int, exists func() error) error {
+	if version == nil || !errors.Is(err, todo.ErrNotFound) || exists() != nil {
+		return err
+	}
+
+	return todo.NewError(todo.ErrPreconditionFailed, "%s has been modified", entity)
+}
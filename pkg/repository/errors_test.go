@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDbError(t *testing.T) {
+	other := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "no rows", err: fmt.Errorf("get: %w", sql.ErrNoRows), want: todo.ErrNotFound},
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, want: todo.ErrConflict},
+		{name: "foreign key violation", err: &pq.Error{Code: "23503"}, want: todo.ErrNotFound},
+		{name: "other error", err: other, want: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, dbError(tt.err, "item"), tt.want, "unexpected error kind")
+		})
+	}
+
+	assert.NoError(t, dbError(nil, "item"), "expected nil to pass through")
+}
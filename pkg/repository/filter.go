@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	todo "github.com/balamuteon/todo_restapi"
+)
+
+// itemFilterConditions переводит фильтр в условия WHERE для таблицы задач с
+// алиасом ti. Значения передаются только параметрами, нумерация с argId.
+func itemFilterConditions(filter todo.ItemFilter, argId int) ([]string, []interface{}) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	if filter.Done != nil {
+		conditions = append(conditions, fmt.Sprintf("ti.done = $%d", argId))
+		args = append(args, *filter.Done)
+		argId++
+	}
+
+	if filter.DueBefore != nil {
+		conditions = append(conditions, fmt.Sprintf("ti.due_at < $%d", argId))
+		args = append(args, *filter.DueBefore)
+		argId++
+	}
+
+	if filter.DueAfter != nil {
+		conditions = append(conditions, fmt.Sprintf("ti.due_at > $%d", argId))
+		args = append(args, *filter.DueAfter)
+		argId++
+	}
+
+	if filter.Priority != nil {
+		conditions = append(conditions, fmt.Sprintf("ti.priority = $%d", argId))
+		args = append(args, *filter.Priority)
+		argId++
+	}
+
+	if filter.Tag != "" {
+		conditions = append(conditions, fmt.Sprintf("ti.tags @> ARRAY[$%d::text]", argId))
+		args = append(args, filter.Tag)
+		argId++
+	}
+
+	if filter.Query != "" {
+		conditions = append(conditions, fmt.Sprintf("(ti.title ILIKE $%[1]d OR ti.description ILIKE $%[1]d)", argId))
+		args = append(args, "%"+escapeLike(filter.Query)+"%")
+		argId++
+	}
+
+	return conditions, args
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы текст искался буквально.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+)
+
+type HistoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewHistoryPostgres(db *sqlx.DB) *HistoryPostgres {
+	return &HistoryPostgres{db: db}
+}
+
+func (r *HistoryPostgres) Create(entry todo.HistoryEntry) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (entity_type, entity_id, actor_id, action, changes)
+												VALUES ($1, $2, $3, $4, $5) RETURNING id`, historyTable)
+	row := r.db.QueryRow(query, entry.EntityType, entry.EntityId, entry.ActorId, entry.Action, entry.Changes)
+	if err := row.Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// addHistory пишет запись аудита в транзакции изменения, чтобы они
+// зафиксировались вместе. Пустые изменения не пишутся.
+func addHistory(db sqlx.Execer, actorId int, entityType string, entityId int, action string, changes todo.FieldChanges) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (entity_type, entity_id, actor_id, action, changes)
+												VALUES ($1, $2, $3, $4, $5)`, historyTable)
+	_, err := db.Exec(query, entityType, entityId, actorId, action, changes)
+
+	return err
+}
+
+// GetForList возвращает историю списка, если пользователь его участник
+// (в том числе для списка в корзине).
+func (r *HistoryPostgres) GetForList(userId, listId, limit, offset int) ([]todo.HistoryEntry, error) {
+	access := fmt.Sprintf("SELECT 1 FROM %s ul WHERE ul.list_id = h.entity_id AND ul.user_id = $3", usersListsTable)
+
+	return r.get(todo.HistoryEntityList, access, userId, listId, limit, offset)
+}
+
+func (r *HistoryPostgres) GetForItem(userId, itemId, limit, offset int) ([]todo.HistoryEntry, error) {
+	access := fmt.Sprintf(`SELECT 1 FROM %s li JOIN %s ul ON ul.list_id = li.list_id
+												WHERE li.item_id = h.entity_id AND ul.user_id = $3`, listsItemsTable, usersListsTable)
+
+	return r.get(todo.HistoryEntityItem, access, userId, itemId, limit, offset)
+}
+
+func (r *HistoryPostgres) get(entityType, access string, userId, entityId, limit, offset int) ([]todo.HistoryEntry, error) {
+	entries := make([]todo.HistoryEntry, 0)
+	query := fmt.Sprintf(`SELECT h.id, h.entity_type, h.entity_id, h.actor_id, u.name AS actor_name, h.action, h.changes, h.created_at
+												FROM %s h LEFT JOIN %s u ON u.id = h.actor_id
+												WHERE h.entity_type = $1 AND h.entity_id = $2 AND EXISTS (%s)
+												ORDER BY h.id DESC
+												LIMIT $4 OFFSET $5`,
+		historyTable, usersTable, access)
+	err := r.db.Select(&entries, query, entityType, entityId, userId, limit, offset)
+
+	return entries, err
+}
+
+func listFields(list todo.TodoList) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       list.Title,
+		"description": list.Description,
+	}
+}
+
+func itemFields(item todo.TodoItem) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       item.Title,
+		"description": item.Description,
+		"done":        item.Done,
+		"due_at":      utcTime(item.DueAt),
+		"assignee_id": item.AssigneeId,
+		"priority":    item.Priority,
+		"tags":        item.Tags,
+	}
+}
+
+// created и deleted описывают появление и исчезновение всех полей сущности.
+func created(fields map[string]interface{}) todo.FieldChanges {
+	changes := make(todo.FieldChanges, len(fields))
+	for name, value := range fields {
+		changes[name] = todo.FieldChange{New: value}
+	}
+
+	return changes
+}
+
+func deleted(fields map[string]interface{}) todo.FieldChanges {
+	changes := make(todo.FieldChanges, len(fields))
+	for name, value := range fields {
+		changes[name] = todo.FieldChange{Old: value}
+	}
+
+	return changes
+}
+
+// diff оставляет только поля, значения которых отличаются.
+func diff(before, after map[string]interface{}) todo.FieldChanges {
+	changes := make(todo.FieldChanges)
+	for name, old := range before {
+		if !sameValue(old, after[name]) {
+			changes[name] = todo.FieldChange{Old: old, New: after[name]}
+		}
+	}
+
+	return changes
+}
+
+func sameValue(a, b interface{}) bool {
+	left, errLeft := json.Marshal(a)
+	right, errRight := json.Marshal(b)
+
+	return errLeft == nil && errRight == nil && bytes.Equal(left, right)
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryPostgres_GetForItem(t *testing.T) {
+	t.Run("entries are visible to list members only", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items, history RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewHistoryPostgres(db)
+		for _, title := range []string{"first", "second", "third"} {
+			_, err := repo.Create(todo.HistoryEntry{
+				EntityType: todo.HistoryEntityItem,
+				EntityId:   itemId,
+				ActorId:    &userId,
+				Action:     todo.HistoryActionUpdate,
+				Changes:    todo.FieldChanges{"title": {Old: "old", New: title}},
+			})
+			assert.NoError(t, err, "expected no error")
+		}
+
+		entries, err := repo.GetForItem(userId, itemId, 2, 0)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, entries, 2, "expected page of two entries")
+		assert.Equal(t, "third", entries[0].Changes["title"].New, "expected newest entry first")
+		assert.Equal(t, "John Doe", *entries[0].ActorName, "expected actor name")
+
+		entries, err = repo.GetForItem(userId, itemId, 2, 2)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, entries, 2, "expected last page of two entries")
+		assert.Equal(t, todo.HistoryActionCreate, entries[1].Action, "expected creation entry last")
+
+		entries, err = repo.GetForItem(999, itemId, 10, 0)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, entries, "expected no entries for non-member")
+
+		entries, err = repo.GetForList(userId, listId, 10, 0)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, entries, 1, "expected list creation entry only")
+	})
+}
+
+func TestHistoryPostgres_RecordedWithChange(t *testing.T) {
+	db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items, history RESTART IDENTITY CASCADE")
+	assert.NoError(t, err, "failed to truncate tables")
+
+	userId := createTestUser(t, authRepo, db)
+	listId, _ := createTestList(t, todoListRepo, userId)
+	itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+	repo := NewHistoryPostgres(db)
+
+	t.Run("update records the diff against the stored row", func(t *testing.T) {
+		title, done := "Important", true
+		err := todoItemRepo.Update(userId, itemId, todo.UpdateItemInput{Title: &title, Done: &done})
+		assert.NoError(t, err, "expected no error")
+
+		entries, err := repo.GetForItem(userId, itemId, 1, 0)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, todo.HistoryActionUpdate, entries[0].Action, "expected update entry")
+		assert.Equal(t, todo.FieldChanges{"done": {Old: false, New: true}}, entries[0].Changes, "expected unchanged title to be skipped")
+	})
+
+	t.Run("failed update records nothing", func(t *testing.T) {
+		title, version := "Stale", 1
+		err := todoItemRepo.Update(userId, itemId, todo.UpdateItemInput{Title: &title, Version: &version})
+		assert.ErrorIs(t, err, todo.ErrPreconditionFailed, "expected version conflict")
+
+		entries, err := repo.GetForItem(userId, itemId, 10, 0)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, entries, 2, "expected creation and first update only")
+	})
+
+	t.Run("delete records the deleted fields", func(t *testing.T) {
+		err := todoListRepo.Delete(userId, listId, nil)
+		assert.NoError(t, err, "expected no error")
+
+		entries, err := repo.GetForList(userId, listId, 1, 0)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, todo.HistoryActionDelete, entries[0].Action, "expected delete entry")
+		assert.Equal(t, "Original List", entries[0].Changes["title"].Old, "expected deleted title")
+	})
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type OutboxPostgres struct {
+	db *sqlx.DB
+}
+
+func NewOutboxPostgres(db *sqlx.DB) *OutboxPostgres {
+	return &OutboxPostgres{db: db}
+}
+
+// addEvent записывает событие в outbox. db должен быть транзакцией изменения:
+// тогда событие уходит, только если изменение зафиксировано. Получатели -
+// участники списков recipientLists, по умолчанию списка listId. itemId 0 -
+// событие списка.
+func addEvent(db sqlx.Execer, eventType string, actorId, listId, itemId int, recipientLists ...int) error {
+	if len(recipientLists) == 0 {
+		recipientLists = []int{listId}
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (event_type, list_id, item_id, actor_id, user_ids)
+												SELECT $1, $2, NULLIF($3, 0), $4, COALESCE(array_agg(DISTINCT user_id), '{}')
+												FROM %s WHERE list_id = ANY($5)`,
+		outboxTable, usersListsTable)
+	_, err := db.Exec(query, eventType, listId, itemId, actorId, pq.Array(recipientLists))
+
+	return err
+}
+
+// addItemEvent записывает событие задачи для участников ее текущего списка.
+func addItemEvent(db sqlx.Ext, eventType string, actorId, itemId int) error {
+	listId, err := itemListId(db, itemId)
+	if err != nil {
+		return err
+	}
+
+	return addEvent(db, eventType, actorId, listId, itemId)
+}
+
+// Claim захватывает неопубликованные события в порядке записи. Как и в
+// ReminderPostgres.ClaimDue, next_attempt_at сдвигается на lease, чтобы после
+// падения процесса событие было подобрано повторно.
+func (r *OutboxPostgres) Claim(limit int, lease time.Duration) ([]todo.OutboxEvent, error) {
+	var events []todo.OutboxEvent
+	query := fmt.Sprintf(`WITH due AS (
+													SELECT id FROM %[1]s
+													WHERE published_at IS NULL AND next_attempt_at <= now()
+													ORDER BY id
+													LIMIT $1
+													FOR UPDATE SKIP LOCKED
+												), claimed AS (
+													UPDATE %[1]s o SET attempts = o.attempts + 1, next_attempt_at = now() + $2::float8 * interval '1 second'
+													FROM due WHERE o.id = due.id
+													RETURNING o.*
+												)
+												SELECT id, event_type, list_id, COALESCE(item_id, 0) AS item_id, actor_id, user_ids, created_at, attempts, published_sinks
+												FROM claimed ORDER BY id`,
+		outboxTable)
+	err := r.db.Select(&events, query, limit, lease.Seconds())
+
+	return events, err
+}
+
+func (r *OutboxPostgres) MarkPublished(eventId int64) error {
+	query := fmt.Sprintf("UPDATE %s SET published_at = now(), last_error = NULL WHERE id = $1", outboxTable)
+	_, err := r.db.Exec(query, eventId)
+
+	return err
+}
+
+// MarkRetry откладывает событие до nextAttemptAt. publishedSinks - получатели,
+// которые его уже приняли: при повторе событие им не отправляется.
+func (r *OutboxPostgres) MarkRetry(eventId int64, nextAttemptAt time.Time, lastErr string, publishedSinks []string) error {
+	query := fmt.Sprintf("UPDATE %s SET next_attempt_at = $1, last_error = $2, published_sinks = $3 WHERE id = $4", outboxTable)
+	_, err := r.db.Exec(query, nextAttemptAt, lastErr, pq.StringArray(publishedSinks), eventId)
+
+	return err
+}
+
+// DeletePublishedBefore удаляет события, опубликованные раньше before.
+func (r *OutboxPostgres) DeletePublishedBefore(before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE published_at < $1", outboxTable)
+	result, err := r.db.Exec(query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
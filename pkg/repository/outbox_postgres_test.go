@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxPostgres_Claim(t *testing.T) {
+	t.Run("writes events with changes and claims them once", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items, outbox RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		done := true
+		assert.NoError(t, todoItemRepo.Update(userId, itemId, todo.UpdateItemInput{Done: &done}), "expected no error")
+		// повторная отметка выполнения не пишет item.completed
+		assert.NoError(t, todoItemRepo.Update(userId, itemId, todo.UpdateItemInput{Done: &done}), "expected no error")
+
+		repo := NewOutboxPostgres(db)
+		events, err := repo.Claim(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+
+		var types []string
+		for _, event := range events {
+			types = append(types, event.Type)
+			assert.Equal(t, listId, event.ListId, "unexpected list id")
+			assert.Equal(t, userId, event.ActorId, "unexpected actor id")
+			assert.Equal(t, []int{userId}, event.Recipients(), "expected list members as recipients")
+			assert.Equal(t, 1, event.Attempts, "expected attempts to be incremented")
+		}
+		assert.Equal(t, []string{
+			todo.EventListCreated,
+			todo.EventItemCreated,
+			todo.EventItemUpdated,
+			todo.EventItemCompleted,
+			todo.EventItemUpdated,
+		}, types, "unexpected events")
+		assert.Equal(t, 0, events[0].ItemId, "expected no item for list event")
+		assert.Equal(t, itemId, events[1].ItemId, "unexpected item id")
+
+		// повторный захват в пределах lease ничего не возвращает
+		claimed, err := repo.Claim(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, claimed, "expected events to stay claimed")
+
+		assert.NoError(t, repo.MarkRetry(events[0].Id, time.Now().Add(-time.Second), "redis is down", []string{"webhooks"}), "expected no error")
+		for _, event := range events[1:] {
+			assert.NoError(t, repo.MarkPublished(event.Id), "expected no error")
+		}
+
+		claimed, err = repo.Claim(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, claimed, 1, "expected failed event to be retried")
+		assert.Equal(t, events[0].Id, claimed[0].Id, "unexpected retried event")
+		assert.Equal(t, 2, claimed[0].Attempts, "expected second attempt")
+		assert.True(t, claimed[0].PublishedTo("webhooks"), "expected accepted sink to be remembered")
+		assert.False(t, claimed[0].PublishedTo("events"), "expected failed sink to be retried")
+
+		deleted, err := repo.DeletePublishedBefore(time.Now().Add(time.Minute))
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, int64(4), deleted, "expected only published events to be deleted")
+	})
+
+	t.Run("rolled back change leaves no event", func(t *testing.T) {
+		db, todoListRepo, _, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, outbox RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+
+		staleVersion := 42
+		title := "stale"
+		err = todoListRepo.Update(userId, listId, todo.UpdateListInput{Title: &title, Version: &staleVersion})
+		assert.Error(t, err, "expected version conflict")
+
+		var count int
+		assert.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM outbox WHERE event_type = $1", todo.EventListUpdated), "expected no error")
+		assert.Equal(t, 0, count, "expected no event for failed update")
+	})
+
+	t.Run("restore and undo record events", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items, undo_tokens, outbox RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, list := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		assert.NoError(t, todoItemRepo.Delete(userId, itemId, nil), "failed to delete item")
+		_, err = NewTrashPostgres(db).RestoreItem(userId, itemId)
+		assert.NoError(t, err, "failed to restore item")
+
+		title := "Renamed"
+		assert.NoError(t, todoListRepo.Update(userId, listId, todo.UpdateListInput{Title: &title}), "failed to update list")
+		undoRepo := NewUndoPostgres(db)
+		ops := []todo.UndoOperation{{Entity: todo.UndoEntityList, List: &list}}
+		assert.NoError(t, undoRepo.Create(userId, "token", ops, time.Now().Add(time.Minute)), "expected no error")
+		_, _, err = undoRepo.Apply(userId, "token")
+		assert.NoError(t, err, "failed to apply undo")
+
+		// первые два события - создание списка и задачи
+		var types []string
+		assert.NoError(t, db.Select(&types, "SELECT event_type FROM outbox WHERE id > 2 ORDER BY id"), "expected no error")
+		assert.Equal(t, []string{todo.EventItemDeleted, todo.EventItemCreated, todo.EventListUpdated, todo.EventListUpdated},
+			types, "expected restore and undo to be published")
+	})
+}
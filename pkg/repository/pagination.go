@@ -0,0 +1,15 @@
+package repository
+
+import todo "github.com/balamuteon/todo_restapi"
+
+// nextPage обрезает выборку до размера страницы. Запрос читает на одну запись
+// больше: если она есть, значит есть и следующая страница.
+func nextPage[T any](rows []T, size int, id func(T) int) ([]T, string) {
+	if len(rows) <= size {
+		return rows, ""
+	}
+
+	rows = rows[:size]
+
+	return rows, todo.EncodeCursor(id(rows[size-1]))
+}
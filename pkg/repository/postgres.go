@@ -7,11 +7,21 @@ import (
 )
 
 const (
-	usersTable      = "users"
-	todoListsTable  = "todo_lists"
-	usersListsTable = "users_lists"
-	todoItemsTable  = "todo_items"
-	listsItemsTable = "lists_items"
+	usersTable             = "users"
+	todoListsTable         = "todo_lists"
+	usersListsTable        = "users_lists"
+	todoItemsTable         = "todo_items"
+	listsItemsTable        = "lists_items"
+	remindersTable         = "reminders"
+	emailOutboxTable       = "email_outbox"
+	commentsTable          = "comments"
+	attachmentsTable       = "attachments"
+	historyTable           = "history"
+	undoTokensTable        = "undo_tokens"
+	savedFiltersTable      = "saved_filters"
+	webhooksTable          = "webhooks"
+	webhookDeliveriesTable = "webhook_deliveries"
+	outboxTable            = "outbox"
 )
 
 type Config struct {
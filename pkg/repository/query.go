@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/balamuteon/todo_restapi/pkg/query"
+)
+
+// queryCompiler переводит выражение языка фильтров в условие WHERE для таблицы
+// задач с алиасом ti. Значения добавляются в args и подставляются параметрами.
+type queryCompiler struct {
+	userId int
+	args   []interface{}
+}
+
+func (c *queryCompiler) compile(node query.Node) (string, error) {
+	switch n := node.(type) {
+	case query.And:
+		return c.binary(n.Left, n.Right, "AND")
+	case query.Or:
+		return c.binary(n.Left, n.Right, "OR")
+	case query.Not:
+		inner, err := c.compile(n.Node)
+		if err != nil {
+			return "", err
+		}
+		// NULL (например, due_at у задачи без срока) отрицание тоже считает несовпадением
+		return fmt.Sprintf("NOT COALESCE(%s, false)", inner), nil
+	case query.Done:
+		return "ti.done = " + c.arg(n.Value), nil
+	case query.Due:
+		if n.None {
+			return "ti.due_at IS NULL", nil
+		}
+
+		conditions := make([]string, 0, 2)
+		if n.From != nil {
+			conditions = append(conditions, "ti.due_at >= "+c.arg(*n.From))
+		}
+		if n.To != nil {
+			conditions = append(conditions, "ti.due_at < "+c.arg(*n.To))
+		}
+		if len(conditions) == 0 {
+			return "ti.due_at IS NOT NULL", nil
+		}
+		return "(" + strings.Join(conditions, " AND ") + ")", nil
+	case query.Text:
+		pattern := c.arg("%" + escapeLike(n.Value) + "%")
+		switch n.Field {
+		case query.TextTitle:
+			return "ti.title ILIKE " + pattern, nil
+		case query.TextDescription:
+			return "ti.description ILIKE " + pattern, nil
+		default:
+			return fmt.Sprintf("(ti.title ILIKE %[1]s OR ti.description ILIKE %[1]s)", pattern), nil
+		}
+	case query.Assignee:
+		switch {
+		case n.None:
+			return "ti.assignee_id IS NULL", nil
+		case n.Me:
+			return "ti.assignee_id = " + c.arg(c.userId), nil
+		default:
+			return "ti.assignee_id = " + c.arg(n.UserId), nil
+		}
+	case query.Tag:
+		return "ti.tags @> ARRAY[" + c.arg(n.Value) + "]::text[]", nil
+	case query.Priority:
+		return "ti.priority " + n.Op + " " + c.arg(n.Value), nil
+	default:
+		return "", fmt.Errorf("unsupported query node %T", node)
+	}
+}
+
+func (c *queryCompiler) binary(left, right query.Node, op string) (string, error) {
+	l, err := c.compile(left)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := c.compile(right)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("(%s %s %s)", l, op, r), nil
+}
+
+func (c *queryCompiler) arg(value interface{}) string {
+	c.args = append(c.args, value)
+
+	return fmt.Sprintf("$%d", len(c.args))
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+)
+
+type ReminderPostgres struct {
+	db *sqlx.DB
+}
+
+func NewReminderPostgres(db *sqlx.DB) *ReminderPostgres {
+	return &ReminderPostgres{db: db}
+}
+
+func (r *ReminderPostgres) Create(userId, itemId int, reminder todo.Reminder) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (item_id, user_id, remind_at, offset_seconds, channel, target)
+												VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`, remindersTable)
+	row := r.db.QueryRow(query, itemId, userId, reminder.RemindAt, reminder.OffsetSeconds, reminder.Channel, reminder.Target)
+	if err := row.Scan(&id); err != nil {
+		return 0, dbError(err, "item")
+	}
+
+	return id, nil
+}
+
+func (r *ReminderPostgres) GetAll(userId, itemId int) ([]todo.Reminder, error) {
+	var reminders []todo.Reminder
+	query := fmt.Sprintf(`SELECT id, item_id, user_id, remind_at, offset_seconds, channel, target, status, attempts, last_error, sent_at
+												FROM %s WHERE item_id = $1 AND user_id = $2 ORDER BY id`, remindersTable)
+	err := r.db.Select(&reminders, query, itemId, userId)
+
+	return reminders, err
+}
+
+func (r *ReminderPostgres) Delete(userId, reminderId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND user_id = $2", remindersTable)
+
+	return execAffected(r.db, "reminder", query, reminderId, userId)
+}
+
+// ClaimDue захватывает сработавшие напоминания. Строки, заблокированные другим
+// экземпляром, пропускаются (SKIP LOCKED), а next_attempt_at сдвигается на lease,
+// чтобы после падения процесса напоминание было подобрано повторно.
+func (r *ReminderPostgres) ClaimDue(limit int, lease time.Duration) ([]todo.DueReminder, error) {
+	var reminders []todo.DueReminder
+	query := fmt.Sprintf(`WITH due AS (
+													SELECT r.id FROM %[1]s r
+													JOIN %[2]s ti ON ti.id = r.item_id
+													WHERE r.status = $1 AND r.next_attempt_at <= now() AND ti.deleted_at IS NULL
+														AND NOT EXISTS (SELECT 1 FROM %[3]s li JOIN %[4]s tl ON tl.id = li.list_id
+															WHERE li.item_id = ti.id AND tl.deleted_at IS NOT NULL)
+														AND COALESCE(r.remind_at, ti.due_at - r.offset_seconds * interval '1 second') <= now()
+													ORDER BY r.next_attempt_at
+													LIMIT $2
+													FOR UPDATE OF r SKIP LOCKED
+												), claimed AS (
+													UPDATE %[1]s r SET attempts = r.attempts + 1, next_attempt_at = now() + $3::float8 * interval '1 second'
+													FROM due WHERE r.id = due.id
+													RETURNING r.*
+												)
+												SELECT c.id, c.item_id, c.user_id, c.remind_at, c.offset_seconds, c.channel, c.target, c.status,
+													c.attempts, c.last_error, c.sent_at, ti.title AS item_title, ti.due_at AS item_due_at,
+													COALESCE(c.remind_at, ti.due_at - c.offset_seconds * interval '1 second') AS fire_at
+												FROM claimed c JOIN %[2]s ti ON ti.id = c.item_id`,
+		remindersTable, todoItemsTable, listsItemsTable, todoListsTable)
+	err := r.db.Select(&reminders, query, todo.ReminderStatusPending, limit, lease.Seconds())
+
+	return reminders, err
+}
+
+func (r *ReminderPostgres) MarkSent(reminderId int) error {
+	query := fmt.Sprintf("UPDATE %s SET status = $1, sent_at = now(), last_error = NULL WHERE id = $2", remindersTable)
+	_, err := r.db.Exec(query, todo.ReminderStatusSent, reminderId)
+
+	return err
+}
+
+func (r *ReminderPostgres) MarkRetry(reminderId int, nextAttemptAt time.Time, lastErr string) error {
+	query := fmt.Sprintf("UPDATE %s SET next_attempt_at = $1, last_error = $2 WHERE id = $3", remindersTable)
+	_, err := r.db.Exec(query, nextAttemptAt, lastErr, reminderId)
+
+	return err
+}
+
+func (r *ReminderPostgres) MarkFailed(reminderId int, lastErr string) error {
+	query := fmt.Sprintf("UPDATE %s SET status = $1, last_error = $2 WHERE id = $3", remindersTable)
+	_, err := r.db.Exec(query, todo.ReminderStatusFailed, lastErr, reminderId)
+
+	return err
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReminderPostgres_Create(t *testing.T) {
+	t.Run("create and get reminders", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewReminderPostgres(db)
+		remindAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		id, err := repo.Create(userId, itemId, todo.Reminder{
+			RemindAt: &remindAt,
+			Channel:  todo.ReminderChannelLog,
+		})
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, 1, id, "expected reminder ID=1")
+
+		reminders, err := repo.GetAll(userId, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, reminders, 1, "expected one reminder")
+		assert.Equal(t, todo.ReminderStatusPending, reminders[0].Status, "expected pending status")
+		assert.True(t, remindAt.Equal(*reminders[0].RemindAt), "expected remind_at to match")
+
+		// чужой пользователь не видит напоминания
+		reminders, err = repo.GetAll(999, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, reminders, "expected no reminders for another user")
+	})
+}
+
+func TestReminderPostgres_Delete(t *testing.T) {
+	t.Run("missing or foreign reminder is not found", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewReminderPostgres(db)
+		remindAt := time.Now().Add(time.Hour)
+		id, err := repo.Create(userId, itemId, todo.Reminder{RemindAt: &remindAt, Channel: todo.ReminderChannelLog})
+		assert.NoError(t, err, "expected no error")
+
+		assert.ErrorIs(t, repo.Delete(999, id), todo.ErrNotFound, "expected foreign reminder to be not found")
+		assert.NoError(t, repo.Delete(userId, id), "expected no error")
+		assert.ErrorIs(t, repo.Delete(userId, id), todo.ErrNotFound, "expected deleted reminder to be not found")
+	})
+}
+
+func TestReminderPostgres_ClaimDue(t *testing.T) {
+	t.Run("claims only due reminders once", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+
+		dueAt := time.Now().Add(10 * time.Minute)
+		itemId, err := todoItemRepo.Create(userId, listId, todo.TodoItem{Title: "Due soon", DueAt: &dueAt})
+		assert.NoError(t, err, "failed to create item")
+
+		repo := NewReminderPostgres(db)
+		// за 15 минут до срока - уже сработало
+		offset := 15 * 60
+		dueId, err := repo.Create(userId, itemId, todo.Reminder{OffsetSeconds: &offset, Channel: todo.ReminderChannelLog})
+		assert.NoError(t, err, "expected no error")
+		// за 5 минут до срока - еще нет
+		offset = 5 * 60
+		_, err = repo.Create(userId, itemId, todo.Reminder{OffsetSeconds: &offset, Channel: todo.ReminderChannelLog})
+		assert.NoError(t, err, "expected no error")
+
+		claimed, err := repo.ClaimDue(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, claimed, 1, "expected one due reminder")
+		assert.Equal(t, dueId, claimed[0].Id, "expected due reminder to be claimed")
+		assert.Equal(t, 1, claimed[0].Attempts, "expected attempts to be incremented")
+		assert.Equal(t, "Due soon", claimed[0].ItemTitle, "expected item title")
+
+		// повторный захват в пределах lease ничего не возвращает
+		claimed, err = repo.ClaimDue(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, claimed, "expected reminder to stay claimed")
+
+		assert.NoError(t, repo.MarkSent(dueId), "expected no error")
+		reminders, err := repo.GetAll(userId, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, todo.ReminderStatusSent, reminders[0].Status, "expected sent status")
+		assert.NotNil(t, reminders[0].SentAt, "expected sent_at to be set")
+	})
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"time"
+
 	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/query"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -12,30 +15,146 @@ type Authorization interface {
 
 type TodoList interface {
 	Create(userId int, list todo.TodoList) (int, error)
-	GetAll(userId int) ([]todo.TodoList, error)
+	GetAll(userId int, page todo.PageInput) ([]todo.TodoList, string, error)
 	GetById(userId, listId int) (todo.TodoList, error)
-	Delete(userId, listId int) error
+	Delete(userId, listId int, version *int) error
 	Update(userId, listId int, input todo.UpdateListInput) error
+	GetUserIds(listId int) ([]int, error)
 }
 
 type TodoItem interface {
-	Create(listId int, item todo.TodoItem) (int, error)
-	GetAll(userId, listId int) ([]todo.TodoItem, error)
+	Create(userId, listId int, item todo.TodoItem) (int, error)
+	GetAll(userId, listId int, filter todo.ItemFilter, page todo.PageInput) ([]todo.TodoItem, string, error)
 	GetById(userId, itemId int) (todo.TodoItem, error)
-	Delete(userId, itemId int) error
+	Delete(userId, itemId int, version *int) error
 	Update(userId, listId int, input todo.UpdateItemInput) error
+	Assign(userId, itemId int, assigneeId *int, version *int) error
+	GetAssigned(userId int, page todo.PageInput) ([]todo.ItemWithList, string, error)
+	GetAllForUser(userId int, filter todo.ItemFilter, expr query.Node, page todo.PageInput) ([]todo.ItemWithList, string, error)
+	GetListId(itemId int) (int, error)
+	Batch(userId int, ops []todo.ItemOperation, atomic bool) ([]todo.ItemOperationResult, error)
+}
+
+type Reminder interface {
+	Create(userId, itemId int, reminder todo.Reminder) (int, error)
+	GetAll(userId, itemId int) ([]todo.Reminder, error)
+	Delete(userId, reminderId int) error
+	ClaimDue(limit int, lease time.Duration) ([]todo.DueReminder, error)
+	MarkSent(reminderId int) error
+	MarkRetry(reminderId int, nextAttemptAt time.Time, lastErr string) error
+	MarkFailed(reminderId int, lastErr string) error
+}
+
+type EmailOutbox interface {
+	Enqueue(recipient, subject, body string) (int, error)
+	ClaimDue(limit int, lease time.Duration) ([]todo.Email, error)
+	MarkSent(emailId int) error
+	MarkRetry(emailId int, nextAttemptAt time.Time, lastErr string) error
+	MarkFailed(emailId int, lastErr string) error
+}
+
+type Comment interface {
+	Create(userId, itemId int, comment todo.Comment) (int, error)
+	GetAll(userId, itemId int) ([]todo.Comment, error)
+	Update(userId, itemId, commentId int, input todo.UpdateCommentInput) error
+	Delete(userId, itemId, commentId int) error
+}
+
+type Attachment interface {
+	Create(attachment todo.Attachment) (int, error)
+	GetAll(userId, itemId int) ([]todo.Attachment, error)
+	GetById(userId, itemId, attachmentId int) (todo.Attachment, error)
+	Delete(userId, itemId, attachmentId int) (string, error)
+}
+
+type Trash interface {
+	GetAll(userId int) (todo.Trash, error)
+	RestoreList(userId, listId int) error
+	RestoreItem(userId, itemId int) (listId int, err error)
+	Purge(before time.Time) ([]string, error)
+}
+
+type History interface {
+	Create(entry todo.HistoryEntry) (int, error)
+	GetForList(userId, listId, limit, offset int) ([]todo.HistoryEntry, error)
+	GetForItem(userId, itemId, limit, offset int) ([]todo.HistoryEntry, error)
+}
+
+type Undo interface {
+	Create(userId int, token string, ops []todo.UndoOperation, expiresAt time.Time) error
+	Apply(userId int, token string) (listIds, itemIds []int, err error)
+	DeleteExpired() (int64, error)
+}
+
+type Search interface {
+	Search(userId int, input todo.SearchInput) ([]todo.SearchHit, error)
+}
+
+type SavedFilter interface {
+	Create(userId int, filter todo.SavedFilter) (int, error)
+	GetAll(userId int) ([]todo.SavedFilter, error)
+	GetById(userId, filterId int) (todo.SavedFilter, error)
+	Update(userId, filterId int, input todo.UpdateSavedFilterInput) error
+	Delete(userId, filterId int) error
+}
+
+type Webhook interface {
+	Create(userId int, webhook todo.Webhook) (int, error)
+	GetAll(userId int) ([]todo.Webhook, error)
+	GetById(userId, webhookId int) (todo.Webhook, error)
+	Update(userId, webhookId int, input todo.UpdateWebhookInput) error
+	Delete(userId, webhookId int) error
+	GetDeliveries(webhookId, limit int) ([]todo.WebhookDelivery, error)
+	Enqueue(userIds []int, eventType string, payload []byte) error
+	ClaimDue(limit int, lease time.Duration) ([]todo.DueWebhookDelivery, error)
+	MarkDelivered(deliveryId, responseStatus int) error
+	MarkRetry(deliveryId int, nextAttemptAt time.Time, responseStatus *int, lastErr string) error
+	MarkFailed(deliveryId int, responseStatus *int, lastErr string) error
+	RecordFailure(webhookId, disableAfter int) (bool, error)
+	DeleteDeliveriesBefore(before time.Time) (int64, error)
+}
+
+type Outbox interface {
+	Claim(limit int, lease time.Duration) ([]todo.OutboxEvent, error)
+	MarkPublished(eventId int64) error
+	MarkRetry(eventId int64, nextAttemptAt time.Time, lastErr string, publishedSinks []string) error
+	DeletePublishedBefore(before time.Time) (int64, error)
 }
 
 type Repository struct {
 	Authorization
 	TodoList
 	TodoItem
+	Reminder
+	EmailOutbox
+	Comment
+	Attachment
+	Trash
+	History
+	Undo
+	Search
+	SavedFilter
+	Webhook
+	Outbox
 }
 
-func NewRepository(db *sqlx.DB) *Repository {
+// NewRepository собирает репозитории. searchConfig - конфигурация
+// полнотекстового поиска Postgres, та же, что в search_vector схемы.
+func NewRepository(db *sqlx.DB, searchConfig string) *Repository {
 	return &Repository{
 		Authorization: NewAuthPostgres(db),
 		TodoList:      NewTodoListPostgres(db),
 		TodoItem:      NewTodoItemPostgres(db),
+		Reminder:      NewReminderPostgres(db),
+		EmailOutbox:   NewEmailOutboxPostgres(db),
+		Comment:       NewCommentPostgres(db),
+		Attachment:    NewAttachmentPostgres(db),
+		Trash:         NewTrashPostgres(db),
+		History:       NewHistoryPostgres(db),
+		Undo:          NewUndoPostgres(db),
+		Search:        NewSearchPostgres(db, searchConfig),
+		SavedFilter:   NewSavedFilterPostgres(db),
+		Webhook:       NewWebhookPostgres(db),
+		Outbox:        NewOutboxPostgres(db),
 	}
 }
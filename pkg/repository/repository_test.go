@@ -17,7 +17,7 @@ func TestRepositiry_NewRepository(t *testing.T) {
 		_, err	:= db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
 		assert.NoError(t, err, "failed to truncate tables")
 
-		repo := NewRepository(db)
+		repo := NewRepository(db, "russian")
 
 		assert.IsType(t, repo.Authorization, &AuthPostgres{})
 		assert.IsType(t, repo.TodoList, &TodoListPostgres{})
@@ -40,7 +40,7 @@ func TestRepositiry_NewRepository(t *testing.T) {
 		assert.NoError(t, err, "expected no error from Create list")
 		assert.Equal(t, 1, listId, "expected list ID=1")
 
-		itemId, err := repo.TodoItem.Create(listId, todo.TodoItem{
+		itemId, err := repo.TodoItem.Create(id, listId, todo.TodoItem{
 			Title:       "Test Item",
 			Description: "Test description",
 		})
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+)
+
+type SavedFilterPostgres struct {
+	db *sqlx.DB
+}
+
+func NewSavedFilterPostgres(db *sqlx.DB) *SavedFilterPostgres {
+	return &SavedFilterPostgres{db: db}
+}
+
+func (r *SavedFilterPostgres) Create(userId int, filter todo.SavedFilter) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (user_id, name, query) VALUES ($1, $2, $3) RETURNING id", savedFiltersTable)
+	row := r.db.QueryRow(query, userId, filter.Name, filter.Query)
+	if err := row.Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *SavedFilterPostgres) GetAll(userId int) ([]todo.SavedFilter, error) {
+	filters := make([]todo.SavedFilter, 0)
+	query := fmt.Sprintf("SELECT id, name, query FROM %s WHERE user_id = $1 ORDER BY id", savedFiltersTable)
+	err := r.db.Select(&filters, query, userId)
+
+	return filters, err
+}
+
+func (r *SavedFilterPostgres) GetById(userId, filterId int) (todo.SavedFilter, error) {
+	var filter todo.SavedFilter
+	query := fmt.Sprintf("SELECT id, name, query FROM %s WHERE id = $1 AND user_id = $2", savedFiltersTable)
+	err := r.db.Get(&filter, query, filterId, userId)
+
+	return filter, dbError(err, "saved filter")
+}
+
+func (r *SavedFilterPostgres) Update(userId, filterId int, input todo.UpdateSavedFilterInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.Name != nil {
+		setValues = append(setValues, fmt.Sprintf("name=$%d", argId))
+		args = append(args, *input.Name)
+		argId++
+	}
+
+	if input.Query != nil {
+		setValues = append(setValues, fmt.Sprintf("query=$%d", argId))
+		args = append(args, *input.Query)
+		argId++
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d AND user_id = $%d",
+		savedFiltersTable, strings.Join(setValues, ", "), argId, argId+1)
+	args = append(args, filterId, userId)
+
+	return execAffected(r.db, "saved filter", query, args...)
+}
+
+func (r *SavedFilterPostgres) Delete(userId, filterId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND user_id = $2", savedFiltersTable)
+
+	return execAffected(r.db, "saved filter", query, filterId, userId)
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedFilterPostgres_Create(t *testing.T) {
+	t.Run("create, update and delete saved filter", func(t *testing.T) {
+		db, _, _, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, saved_filters RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+
+		repo := NewSavedFilterPostgres(db)
+		filter := todo.SavedFilter{Name: "Work", Query: "title:work done:false"}
+		id, err := repo.Create(userId, filter)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, 1, id, "expected filter ID=1")
+
+		// фильтры видны только владельцу
+		_, err = repo.GetById(999, id)
+		assert.Error(t, err, "expected error for another user")
+
+		query := "title:work"
+		assert.NoError(t, repo.Update(userId, id, todo.UpdateSavedFilterInput{Query: &query}), "expected no error")
+		dbFilter, err := repo.GetById(userId, id)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, todo.SavedFilter{Id: id, Name: filter.Name, Query: query}, dbFilter, "expected updated filter")
+
+		assert.NoError(t, repo.Delete(userId, id), "expected no error")
+		filters, err := repo.GetAll(userId)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, filters, "expected filter to be deleted")
+	})
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"
+
+// htmlEntities - замены для экранирования HTML, & идет первым.
+var htmlEntities = [][2]string{{"&", "&amp;"}, {"<", "&lt;"}, {">", "&gt;"}, {`"`, "&quot;"}, {"'", "&#39;"}}
+
+type SearchPostgres struct {
+	db *sqlx.DB
+	// config должна совпадать с конфигурацией, которой построены
+	// search_vector в схеме, иначе стеммы запроса и документа разойдутся
+	config string
+}
+
+func NewSearchPostgres(db *sqlx.DB, config string) *SearchPostgres {
+	return &SearchPostgres{db: db, config: config}
+}
+
+// CheckSearchConfig проверяет, что search_vector в схеме построены
+// конфигурацией config. Иначе поиск не падает, а молча перестает находить
+// слова в других формах, поэтому расхождение лучше поймать при запуске.
+func CheckSearchConfig(db *sqlx.DB, config string) error {
+	var mismatched []string
+	query := `SELECT c.relname
+						FROM pg_attribute a
+						JOIN pg_class c ON c.oid = a.attrelid
+						JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+						WHERE a.attname = 'search_vector' AND c.relname = ANY($1)
+							AND pg_get_expr(d.adbin, d.adrelid) NOT LIKE '%' || quote_literal($2::regconfig::text) || '::regconfig%'`
+	if err := db.Select(&mismatched, query, pq.Array([]string{todoListsTable, todoItemsTable}), config); err != nil {
+		return fmt.Errorf("failed to check search config %q: %w", config, err)
+	}
+
+	if len(mismatched) > 0 {
+		return fmt.Errorf("search config %q does not match search_vector of %s", config, strings.Join(mismatched, ", "))
+	}
+
+	return nil
+}
+
+// Search ищет по спискам пользователя и задачам в них. Подсветка считается
+// только для попавших в выдачу строк: ts_headline заметно дороже ранжирования.
+// Заголовок и описание в выдаче - HTML: текст экранирован, совпадения в <mark>.
+func (r *SearchPostgres) Search(userId int, input todo.SearchInput) ([]todo.SearchHit, error) {
+	hits := make([]todo.SearchHit, 0)
+
+	query := fmt.Sprintf(`WITH q AS (SELECT websearch_to_tsquery($4::regconfig, $2) AS query),
+												hits AS (
+													SELECT '%[2]s' AS type, tl.id, tl.id AS list_id, tl.title, tl.description,
+														ts_rank(tl.search_vector, q.query) AS rank
+													FROM %[4]s tl JOIN %[5]s ul ON ul.list_id = tl.id, q
+													WHERE ul.user_id = $1 AND tl.deleted_at IS NULL AND tl.search_vector @@ q.query
+													UNION ALL
+													SELECT '%[3]s', ti.id, li.list_id, ti.title, ti.description,
+														ts_rank(ti.search_vector, q.query)
+													FROM %[6]s ti
+													JOIN %[7]s li ON li.item_id = ti.id
+													JOIN %[5]s ul ON ul.list_id = li.list_id
+													JOIN %[4]s tl ON tl.id = li.list_id, q
+													WHERE ul.user_id = $1 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+														AND ti.search_vector @@ q.query
+													ORDER BY rank DESC, type, id
+													LIMIT $3
+												)
+												SELECT h.type, h.id, h.list_id,
+													ts_headline($4::regconfig, %[1]s, q.query, '%[8]s') AS title,
+													ts_headline($4::regconfig, %[9]s, q.query, '%[8]s') AS description,
+													h.rank
+												FROM hits h, q
+												ORDER BY h.rank DESC, h.type, h.id`,
+		htmlEscaped("h.title"), todo.SearchTypeList, todo.SearchTypeItem,
+		todoListsTable, usersListsTable, todoItemsTable, listsItemsTable, headlineOptions, htmlEscaped("h.description"))
+	err := r.db.Select(&hits, query, userId, input.Query, input.Size(), r.config)
+
+	return hits, err
+}
+
+// htmlEscaped оборачивает SQL-выражение в экранирование HTML. ts_headline
+// пропускает разметку из текста как есть, поэтому экранировать нужно до
+// подсветки. Сущности вроде &lt; парсер поиска словами не считает, так что
+// совпадения не теряются.
+func htmlEscaped(expr string) string {
+	for _, entity := range htmlEntities {
+		expr = fmt.Sprintf("replace(%s, '%s', '%s')", expr, strings.ReplaceAll(entity[0], "'", "''"), entity[1])
+	}
+
+	return expr
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"testing"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchPostgres_Search(t *testing.T) {
+	t.Run("finds lists and items in russian and english", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, err := todoListRepo.Create(userId, todo.TodoList{Title: "Покупки", Description: "Groceries"})
+		assert.NoError(t, err, "failed to create list")
+		_, err = todoItemRepo.Create(userId, listId, todo.TodoItem{Title: "Купить молоко"})
+		assert.NoError(t, err, "failed to create item")
+		_, err = todoItemRepo.Create(userId, listId, todo.TodoItem{Title: "Call the plumbers"})
+		assert.NoError(t, err, "failed to create item")
+
+		repo := NewSearchPostgres(db, "russian")
+
+		// словоформа отличается от сохраненной - срабатывает стемминг
+		hits, err := repo.Search(userId, todo.SearchInput{Query: "молока"})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, hits, 1, "expected one hit")
+		assert.Equal(t, todo.SearchTypeItem, hits[0].Type, "expected item hit")
+		assert.Equal(t, listId, hits[0].ListId, "expected list of item")
+		assert.Contains(t, hits[0].Title, "<mark>молоко</mark>", "expected highlighted match")
+
+		hits, err = repo.Search(userId, todo.SearchInput{Query: "plumber"})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, hits, 1, "expected english stemming")
+
+		hits, err = repo.Search(userId, todo.SearchInput{Query: "groceries"})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, hits, 1, "expected list hit")
+		assert.Equal(t, todo.SearchTypeList, hits[0].Type, "expected list hit")
+
+		// чужие списки не ищутся
+		hits, err = repo.Search(999, todo.SearchInput{Query: "молоко"})
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, hits, "expected no hits for non-member")
+	})
+
+	t.Run("escapes html before highlighting", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		_, err = todoItemRepo.Create(userId, listId, todo.TodoItem{
+			Title:       `<img src=x onerror="alert(1)"> pay bills`,
+			Description: "Tom & Jerry's bills",
+		})
+		assert.NoError(t, err, "failed to create item")
+
+		hits, err := NewSearchPostgres(db, "russian").Search(userId, todo.SearchInput{Query: "bills"})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, hits, 1, "expected one hit")
+		assert.Equal(t, `&lt;img src=x onerror=&quot;alert(1)&quot;&gt; pay <mark>bills</mark>`, hits[0].Title, "expected escaped title")
+		assert.Equal(t, `Tom &amp; Jerry&#39;s <mark>bills</mark>`, hits[0].Description, "expected escaped description")
+	})
+}
+
+func TestCheckSearchConfig(t *testing.T) {
+	db, _, _, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	assert.NoError(t, CheckSearchConfig(db, "russian"), "expected config of the schema to pass")
+	assert.Error(t, CheckSearchConfig(db, "english"), "expected another config to be rejected")
+	assert.Error(t, CheckSearchConfig(db, "klingon"), "expected unknown config to be rejected")
+}
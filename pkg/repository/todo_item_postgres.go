@@ -1,12 +1,13 @@
 package repository
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 
 	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/query"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type TodoItemPostgres struct {
@@ -17,74 +18,187 @@ func NewTodoItemPostgres(db *sqlx.DB) *TodoItemPostgres {
 	return &TodoItemPostgres{db: db}
 }
 
-func (r *TodoItemPostgres) Create(listId int, item todo.TodoItem) (int, error) {
-	tx, err := r.db.Begin()
+func (r *TodoItemPostgres) Create(userId, listId int, item todo.TodoItem) (int, error) {
+	tx, err := r.db.Beginx()
 	if err != nil {
 		return 0, err
 	}
 
-	var itemId int
-	createItemQuery := fmt.Sprintf("INSERT INTO %s (title, description) values ($1, $2) RETURNING id", todoItemsTable)
-
-	row := tx.QueryRow(createItemQuery, item.Title, item.Description)
-	if err := row.Scan(&itemId); err != nil {
+	itemId, err := createItem(tx, userId, listId, item)
+	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
-	createListItemsQuery := fmt.Sprintf("INSERT INTO %s (list_id, item_id) values ($1, $2)", listsItemsTable)
-	_, err = tx.Exec(createListItemsQuery, listId, itemId)
+	return itemId, tx.Commit()
+}
+
+// createItem создает задачу и связь со списком; db должен быть транзакцией.
+// Здесь и в остальных изменениях задач userId - автор события в outbox и
+// записи истории.
+func createItem(db sqlx.Ext, userId, listId int, item todo.TodoItem) (int, error) {
+	var stored todo.TodoItem
+	createItemQuery := fmt.Sprintf(`INSERT INTO %s (title, description, due_at, priority, tags)
+												values ($1, $2, $3, $4, COALESCE($5::text[], '{}'))
+												RETURNING id, title, description, done, due_at, assignee_id, priority, tags, version`, todoItemsTable)
+
+	err := sqlx.Get(db, &stored, createItemQuery, item.Title, item.Description, item.DueAt, item.Priority, item.Tags)
 	if err != nil {
-		tx.Rollback()
 		return 0, err
 	}
+	itemId := stored.Id
 
-	return itemId, tx.Commit()
+	createListItemsQuery := fmt.Sprintf("INSERT INTO %s (list_id, item_id) values ($1, $2)", listsItemsTable)
+	if _, err := db.Exec(createListItemsQuery, listId, itemId); err != nil {
+		return 0, dbError(err, "list")
+	}
+
+	if err := addEvent(db, todo.EventItemCreated, userId, listId, itemId); err != nil {
+		return 0, err
+	}
+
+	if err := addItemHistory(db, userId, itemId, todo.HistoryActionCreate, created(itemFields(stored))); err != nil {
+		return 0, err
+	}
+
+	return itemId, nil
 }
 
-func (r *TodoItemPostgres) GetAll(userId, listId int) ([]todo.TodoItem, error) {
-	var items []todo.TodoItem
-	query := fmt.Sprintf(`SELECT ti.id, ti.title, ti.description, ti.done FROM  %s ti
+func (r *TodoItemPostgres) GetAll(userId, listId int, filter todo.ItemFilter, page todo.PageInput) ([]todo.TodoItem, string, error) {
+	items := make([]todo.TodoItem, 0)
+
+	afterId, err := page.AfterId()
+	if err != nil {
+		return nil, "", err
+	}
+
+	conditions := []string{
+		"li.list_id = $1", "ul.user_id = $2", "ti.deleted_at IS NULL", "tl.deleted_at IS NULL", "ti.id > $3",
+	}
+	args := []interface{}{listId, userId, afterId}
+
+	filterConditions, filterArgs := itemFilterConditions(filter, len(args)+1)
+	conditions = append(conditions, filterConditions...)
+	args = append(args, filterArgs...)
+
+	query := fmt.Sprintf(`SELECT ti.id, ti.title, ti.description, ti.done, ti.due_at, ti.assignee_id, ti.priority, ti.tags, ti.version FROM  %s ti
 												JOIN %s li ON li.item_id = ti.id
 												JOIN %s ul ON ul.list_id = li.list_id 
-												WHERE li.list_id = $1 AND ul.user_id = $2`,
-		todoItemsTable, listsItemsTable, usersListsTable)
-	if err := r.db.Select(&items, query, listId, userId); err != nil {
-		return nil, err
-	}
+												JOIN %s tl ON tl.id = li.list_id
+												WHERE %s
+												ORDER BY ti.id LIMIT $%d`,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable, strings.Join(conditions, " AND "), len(args)+1)
+	args = append(args, page.Size()+1)
 
-	if len(items) == 0 {
-		return nil, errors.New("not found")
+	if err := r.db.Select(&items, query, args...); err != nil {
+		return nil, "", err
 	}
 
-	return items, nil
+	items, nextCursor := nextPage(items, page.Size(), func(i todo.TodoItem) int { return i.Id })
+
+	return items, nextCursor, nil
 }
 
 func (r *TodoItemPostgres) GetById(userId, itemId int) (todo.TodoItem, error) {
+	return getItem(r.db, userId, itemId)
+}
+
+func getItem(db sqlx.Queryer, userId, itemId int) (todo.TodoItem, error) {
+	return selectItem(db, userId, itemId, "")
+}
+
+// lockItem читает задачу и блокирует ее строку до конца транзакции: снимок
+// для истории остается верным до изменения, а одновременные изменения задачи
+// выполняются по очереди.
+func lockItem(db sqlx.Queryer, userId, itemId int) (todo.TodoItem, error) {
+	return selectItem(db, userId, itemId, "FOR UPDATE OF ti")
+}
+
+func selectItem(db sqlx.Queryer, userId, itemId int, lock string) (todo.TodoItem, error) {
 	var item todo.TodoItem
-	query := fmt.Sprintf(`SELECT ti.id, ti.title, ti.description, ti.done FROM  %s ti
+	query := fmt.Sprintf(`SELECT ti.id, ti.title, ti.description, ti.done, ti.due_at, ti.assignee_id, ti.priority, ti.tags, ti.version FROM  %s ti
 												JOIN %s li ON li.item_id = ti.id
 												JOIN %s ul ON ul.list_id = li.list_id 
-												WHERE ti.id = $1 AND ul.user_id = $2`,
-		todoItemsTable, listsItemsTable, usersListsTable)
-	if err := r.db.Get(&item, query, itemId, userId); err != nil {
-		return item, err
+												JOIN %s tl ON tl.id = li.list_id
+												WHERE ti.id = $1 AND ul.user_id = $2 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+												%s`,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable, lock)
+	if err := sqlx.Get(db, &item, query, itemId, userId); err != nil {
+		return item, dbError(err, "item")
 	}
 
 	return item, nil
 }
 
-func (r *TodoItemPostgres) Delete(userId, itemId int) error {
-	query := fmt.Sprintf(`DELETE FROM %s ti USING %s li, %s ul 
-												WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND ul.user_id = $1 AND ti.id = $2`,
-		todoItemsTable, listsItemsTable, usersListsTable)
+// Delete переносит задачу в корзину. version - ожидаемая версия задачи, nil -
+// без проверки.
+func (r *TodoItemPostgres) Delete(userId, itemId int, version *int) error {
+	return r.inTx(func(tx *sqlx.Tx) error {
+		return deleteItem(tx, userId, itemId, version)
+	})
+}
 
-	_, err := r.db.Exec(query, userId, itemId)
+// inTx выполняет изменение в транзакции, чтобы оно, его событие в outbox и
+// запись истории зафиксировались вместе.
+func (r *TodoItemPostgres) inTx(fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func deleteItem(db sqlx.Ext, userId, itemId int, version *int) error {
+	item, err := lockItem(db, userId, itemId)
+	if err != nil {
+		return err
+	}
+
+	// задача уходит в корзину, окончательно ее удаляет фоновая очистка
+	query := fmt.Sprintf(`UPDATE %s ti SET deleted_at = now()
+												FROM %s li, %s ul, %s tl
+												WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND tl.id = li.list_id
+													AND ul.user_id = $1 AND ti.id = $2 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+													AND ($3::int IS NULL OR ti.version = $3)`,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable)
 
-	return err
+	err = execAffected(db, "item", query, userId, itemId, version)
+	if err != nil {
+		return versionError(err, "item", version, func() error {
+			_, err := getItem(db, userId, itemId)
+			return err
+		})
+	}
+
+	if err := addItemEvent(db, todo.EventItemDeleted, userId, itemId); err != nil {
+		return err
+	}
+
+	return addItemHistory(db, userId, itemId, todo.HistoryActionDelete, deleted(itemFields(item)))
 }
 
 func (r *TodoItemPostgres) Update(userId, itemId int, input todo.UpdateItemInput) error {
+	return r.inTx(func(tx *sqlx.Tx) error {
+		return updateItem(tx, userId, itemId, input)
+	})
+}
+
+// updateItem кроме item.updated пишет item.completed, если задача стала
+// выполненной.
+func updateItem(db sqlx.Ext, userId, itemId int, input todo.UpdateItemInput) error {
+	// строка блокируется до изменения, чтобы из двух одновременных отметок
+	// выполнения item.completed записала только одна
+	before, err := lockItem(db, userId, itemId)
+	if err != nil {
+		return err
+	}
+
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
@@ -107,14 +221,281 @@ func (r *TodoItemPostgres) Update(userId, itemId int, input todo.UpdateItemInput
 		argId++
 	}
 
+	if input.DueAt != nil {
+		setValues = append(setValues, fmt.Sprintf("due_at=$%d", argId))
+		args = append(args, *input.DueAt)
+		argId++
+	}
+
+	if input.ClearDueAt {
+		setValues = append(setValues, "due_at=NULL")
+	}
+
+	if input.Priority != nil {
+		setValues = append(setValues, fmt.Sprintf("priority=$%d", argId))
+		args = append(args, *input.Priority)
+		argId++
+	}
+
+	if input.Tags != nil {
+		setValues = append(setValues, fmt.Sprintf("tags=$%d", argId))
+		args = append(args, pq.StringArray(*input.Tags))
+		argId++
+	}
+
 	setQuery := strings.Join(setValues, ", ")
-	query := fmt.Sprintf(`UPDATE %s ti SET %s
-												FROM %s li, %s ul
-												WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND ul.user_id = $%d AND ti.id = $%d`,
-		todoItemsTable, setQuery, listsItemsTable, usersListsTable, argId, argId+1)
-	args = append(args, userId, itemId)
+	query := fmt.Sprintf(`UPDATE %s ti SET %s, version = ti.version + 1
+												FROM %s li, %s ul, %s tl
+												WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND tl.id = li.list_id
+													AND ul.user_id = $%d AND ti.id = $%d AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+													AND ($%d::int IS NULL OR ti.version = $%d)`,
+		todoItemsTable, setQuery, listsItemsTable, usersListsTable, todoListsTable, argId, argId+1, argId+2, argId+2)
+	args = append(args, userId, itemId, input.Version)
+
+	err = execAffected(db, "item", query, args...)
+	if err != nil {
+		return versionError(err, "item", input.Version, func() error {
+			_, err := getItem(db, userId, itemId)
+			return err
+		})
+	}
+
+	if err := addItemEvent(db, todo.EventItemUpdated, userId, itemId); err != nil {
+		return err
+	}
+
+	if input.Done != nil && *input.Done && !before.Done {
+		if err := addItemEvent(db, todo.EventItemCompleted, userId, itemId); err != nil {
+			return err
+		}
+	}
+
+	return addItemUpdateHistory(db, userId, before)
+}
+
+// addItemUpdateHistory пишет в историю разницу между снимком задачи до
+// изменения и ее состоянием в транзакции после него.
+func addItemUpdateHistory(db sqlx.Ext, userId int, before todo.TodoItem) error {
+	after, err := getItem(db, userId, before.Id)
+	if err != nil {
+		return err
+	}
+
+	return addItemHistory(db, userId, before.Id, todo.HistoryActionUpdate, diff(itemFields(before), itemFields(after)))
+}
+
+func addItemHistory(db sqlx.Execer, userId, itemId int, action string, changes todo.FieldChanges) error {
+	return addHistory(db, userId, todo.HistoryEntityItem, itemId, action, changes)
+}
+
+// moveItem переносит задачу в другой список пользователя. Событие получают
+// участники обоих списков.
+func moveItem(db sqlx.Ext, userId, itemId, listId int, version *int) error {
+	if _, err := lockItem(db, userId, itemId); err != nil {
+		return err
+	}
+
+	fromListId, err := itemListId(db, itemId)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`WITH moved AS (
+													UPDATE %[1]s li SET list_id = $3
+													FROM %[2]s ul, %[3]s ti, %[4]s tl
+													WHERE li.item_id = $2 AND ul.list_id = li.list_id AND ti.id = li.item_id AND tl.id = li.list_id
+														AND ul.user_id = $1 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+														AND ($4::int IS NULL OR ti.version = $4)
+														AND EXISTS (SELECT 1 FROM %[2]s tul JOIN %[4]s ttl ON ttl.id = tul.list_id
+															WHERE tul.user_id = $1 AND tul.list_id = $3 AND ttl.deleted_at IS NULL)
+													RETURNING li.item_id
+												)
+												UPDATE %[3]s SET version = version + 1 WHERE id IN (SELECT item_id FROM moved)`,
+		listsItemsTable, usersListsTable, todoItemsTable, todoListsTable)
+
+	err = execAffected(db, "item", query, userId, itemId, listId, version)
+	if err != nil {
+		return versionError(err, "item", version, func() error {
+			_, err := getItem(db, userId, itemId)
+			return err
+		})
+	}
+
+	if err := addEvent(db, todo.EventItemMoved, userId, listId, itemId, fromListId, listId); err != nil {
+		return err
+	}
+
+	changes := todo.FieldChanges{"list_id": {Old: fromListId, New: listId}}
+	return addItemHistory(db, userId, itemId, todo.HistoryActionUpdate, changes)
+}
+
+// Batch выполняет операции над задачами в одной транзакции и возвращает
+// результат каждой. В атомарном режиме первая ошибка откатывает весь пакет и
+// возвращается как *todo.BatchOperationError вместе с результатами до нее
+// включительно. Иначе каждая операция выполняется в своей точке сохранения, и
+// ошибка откатывает только ее.
+func (r *TodoItemPostgres) Batch(userId int, ops []todo.ItemOperation, atomic bool) ([]todo.ItemOperationResult, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]todo.ItemOperationResult, 0, len(ops))
+	for i, op := range ops {
+		if !atomic {
+			if _, err := tx.Exec("SAVEPOINT item_operation"); err != nil {
+				return nil, err
+			}
+		}
+
+		id, err := applyItemOperation(tx, userId, op)
+		results = append(results, todo.ItemOperationResult{Id: id, Err: err})
+
+		switch {
+		case err != nil && atomic:
+			return results, &todo.BatchOperationError{Index: i, Err: err}
+		case err != nil:
+			_, err = tx.Exec("ROLLBACK TO SAVEPOINT item_operation")
+		case !atomic:
+			_, err = tx.Exec("RELEASE SAVEPOINT item_operation")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, tx.Commit()
+}
+
+func applyItemOperation(tx *sqlx.Tx, userId int, op todo.ItemOperation) (int, error) {
+	switch op.Op {
+	case todo.ItemOpCreate:
+		return createItem(tx, userId, op.ListId, *op.Item)
+	case todo.ItemOpUpdate:
+		return op.Id, updateItem(tx, userId, op.Id, *op.Changes)
+	case todo.ItemOpDelete:
+		return op.Id, deleteItem(tx, userId, op.Id, op.Version)
+	case todo.ItemOpMove:
+		return op.Id, moveItem(tx, userId, op.Id, op.ListId, op.Version)
+	default:
+		return op.Id, todo.NewError(todo.ErrValidation, "unknown operation %q", op.Op)
+	}
+}
+
+// Assign назначает исполнителя задачи. version - ожидаемая версия задачи, nil -
+// без проверки.
+func (r *TodoItemPostgres) Assign(userId, itemId int, assigneeId *int, version *int) error {
+	query := fmt.Sprintf(`UPDATE %s ti SET assignee_id = $1, version = ti.version + 1
+												FROM %s li, %s ul, %s tl
+												WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND tl.id = li.list_id
+													AND ul.user_id = $2 AND ti.id = $3 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+													AND ($4::int IS NULL OR ti.version = $4)`,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable)
+
+	return r.inTx(func(tx *sqlx.Tx) error {
+		before, err := lockItem(tx, userId, itemId)
+		if err != nil {
+			return err
+		}
+
+		if err := execAffected(tx, "item", query, assigneeId, userId, itemId, version); err != nil {
+			return versionError(err, "item", version, func() error {
+				_, err := getItem(tx, userId, itemId)
+				return err
+			})
+		}
+
+		if err := addItemEvent(tx, todo.EventItemUpdated, userId, itemId); err != nil {
+			return err
+		}
+
+		return addItemUpdateHistory(tx, userId, before)
+	})
+}
+
+func (r *TodoItemPostgres) GetAssigned(userId int, page todo.PageInput) ([]todo.ItemWithList, string, error) {
+	items := make([]todo.ItemWithList, 0)
+
+	afterId, err := page.AfterId()
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := fmt.Sprintf(`SELECT ti.id, ti.title, ti.description, ti.done, ti.due_at, ti.assignee_id, ti.priority, ti.tags, ti.version,
+													tl.id AS list_id, tl.title AS list_title
+												FROM %s ti
+												JOIN %s li ON li.item_id = ti.id
+												JOIN %s tl ON tl.id = li.list_id
+												JOIN %s ul ON ul.list_id = li.list_id
+												WHERE ti.assignee_id = $1 AND ul.user_id = $1 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+													AND ti.id > $2
+												ORDER BY ti.id LIMIT $3`,
+		todoItemsTable, listsItemsTable, todoListsTable, usersListsTable)
+	if err := r.db.Select(&items, query, userId, afterId, page.Size()+1); err != nil {
+		return nil, "", err
+	}
+
+	items, nextCursor := nextPage(items, page.Size(), func(i todo.ItemWithList) int { return i.Id })
+
+	return items, nextCursor, nil
+}
+
+// GetAllForUser возвращает задачи из всех списков пользователя вместе с id и
+// названием списка. expr - необязательное выражение языка фильтров, оно
+// применяется вместе с filter.
+func (r *TodoItemPostgres) GetAllForUser(userId int, filter todo.ItemFilter, expr query.Node, page todo.PageInput) ([]todo.ItemWithList, string, error) {
+	items := make([]todo.ItemWithList, 0)
+
+	afterId, err := page.AfterId()
+	if err != nil {
+		return nil, "", err
+	}
+
+	conditions := []string{
+		"ul.user_id = $1", "ti.deleted_at IS NULL", "tl.deleted_at IS NULL", "ti.id > $2",
+	}
+	compiler := &queryCompiler{userId: userId, args: []interface{}{userId, afterId}}
+
+	filterConditions, filterArgs := itemFilterConditions(filter, len(compiler.args)+1)
+	conditions = append(conditions, filterConditions...)
+	compiler.args = append(compiler.args, filterArgs...)
+
+	if expr != nil {
+		where, err := compiler.compile(expr)
+		if err != nil {
+			return nil, "", err
+		}
+		conditions = append(conditions, where)
+	}
+
+	sqlQuery := fmt.Sprintf(`SELECT ti.id, ti.title, ti.description, ti.done, ti.due_at, ti.assignee_id, ti.priority, ti.tags, ti.version,
+													tl.id AS list_id, tl.title AS list_title
+												FROM %s ti
+												JOIN %s li ON li.item_id = ti.id
+												JOIN %s tl ON tl.id = li.list_id
+												JOIN %s ul ON ul.list_id = li.list_id
+												WHERE %s
+												ORDER BY ti.id LIMIT %s`,
+		todoItemsTable, listsItemsTable, todoListsTable, usersListsTable,
+		strings.Join(conditions, " AND "), compiler.arg(page.Size()+1))
+	if err := r.db.Select(&items, sqlQuery, compiler.args...); err != nil {
+		return nil, "", err
+	}
+
+	items, nextCursor := nextPage(items, page.Size(), func(i todo.ItemWithList) int { return i.Id })
+
+	return items, nextCursor, nil
+}
+
+func (r *TodoItemPostgres) GetListId(itemId int) (int, error) {
+	return itemListId(r.db, itemId)
+}
 
-	_, err := r.db.Exec(query, args...)
+func itemListId(db sqlx.Queryer, itemId int) (int, error) {
+	var listId int
+	query := fmt.Sprintf("SELECT list_id FROM %s WHERE item_id = $1", listsItemsTable)
+	err := sqlx.Get(db, &listId, query, itemId)
 
-	return err
+	return listId, dbError(err, "item")
 }
@@ -3,8 +3,10 @@ package repository
 import (
 	// "fmt"
 	"testing"
+	"time"
 
 	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/query"
 	_ "github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
@@ -77,11 +79,11 @@ func TestTodoItemPostgres_Create(t *testing.T) {
 		listId, _ := createTestList(t, todoListRepo, userId)
 
 		// создаем айтем
-		itemId, item := createTestItem(t, todoItemRepo, listId)
+		itemId, item := createTestItem(t, todoItemRepo, userId, listId)
 
 		// проверяем что айтем создан
 		dbItem := todo.TodoItem{}
-		err = db.Get(&dbItem, "SELECT id, title, description, done FROM todo_items WHERE id=$1", itemId)
+		err = db.Get(&dbItem, "SELECT id, title, description, done, tags, version FROM todo_items WHERE id=$1", itemId)
 		assert.NoError(t, err, "expected no error")
 		assert.Equal(t, item, dbItem, "item in db doesn't mathc")
 
@@ -91,7 +93,7 @@ func TestTodoItemPostgres_Create(t *testing.T) {
 		assert.NoError(t, err, "failed to check lists_items")
 		assert.Equal(t, 1, count, "expected one record in lists_items")
 		// добавим еще один итем
-		itemId, err = todoItemRepo.Create(listId, item)
+		itemId, err = todoItemRepo.Create(userId, listId, item)
 		err = db.Get(&count, "SELECT COUNT(*) FROM lists_items WHERE list_id=$1", listId)
 		assert.NoError(t, err, "failed to check lists_items")
 		assert.Equal(t, 2, count, "expected two record in lists_items")
@@ -108,7 +110,7 @@ func TestTodoItemPostgres_Create(t *testing.T) {
 		// Пытаемся создать элемент с несуществующим listId
 		item := todo.TodoItem{}
 		invalidListId := 999
-		itemId, err := todoItemRepo.Create(invalidListId, item)
+		itemId, err := todoItemRepo.Create(1, invalidListId, item)
 		assert.Error(t, err, "expected error for invalid listId")
 		assert.Equal(t, 0, itemId, "expected item ID=0")
 	})
@@ -136,28 +138,31 @@ func TestTodoItemPostgres_GetAll(t *testing.T) {
 				Title:       "1",
 				Description: "One",
 				Done:        false,
+				Tags:        []string{},
 			},
 			{
 				Title:       "2",
 				Description: "Two",
 				Done:        false,
+				Tags:        []string{},
 			},
 			{
 				Title:       "3",
 				Description: "Three",
 				Done:        false,
+				Tags:        []string{},
 			},
 		}
 		// создаем 3 элемента
 		for idx := range items {
-			itemId, err := todoItemRepo.Create(listId, items[idx])
+			itemId, err := todoItemRepo.Create(userId, listId, items[idx])
 			assert.NoError(t, err, "failed to create item %d", idx+1)
 			assert.Equal(t, idx+1, itemId, "expected item ID=%d", idx+1)
 			items[idx].Id = itemId
 		}
 
 		// Получаем элементы
-		dbItems, err := todoItemRepo.GetAll(userId, listId)
+		dbItems, _, err := todoItemRepo.GetAll(userId, listId, todo.ItemFilter{}, todo.PageInput{})
 		assert.NoError(t, err, "failed to get items")
 		assert.Equal(t, len(items), len(dbItems), "expected len of item arrays to be equal")
 		assert.NotNil(t, dbItems, "expected items to be not nil")
@@ -182,9 +187,109 @@ func TestTodoItemPostgres_GetAll(t *testing.T) {
 		assert.NotZero(t, list, "expected non-zero TodoList")
 
 		// Ожидаем что элементов нет
-		dbItems, err := todoItemRepo.GetAll(userId, listId)
+		dbItems, _, err := todoItemRepo.GetAll(userId, listId, todo.ItemFilter{}, todo.PageInput{})
 		assert.Equal(t, len(dbItems), 0, "expected len of item arrays to be zero")
 	})
+
+	t.Run("paginates with cursor", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		for idx := 0; idx < 3; idx++ {
+			_, err := todoItemRepo.Create(userId, listId, todo.TodoItem{Title: "Page item"})
+			assert.NoError(t, err, "failed to create item %d", idx+1)
+		}
+
+		firstPage, cursor, err := todoItemRepo.GetAll(userId, listId, todo.ItemFilter{}, todo.PageInput{Limit: 2})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, firstPage, 2, "expected full first page")
+		assert.NotEmpty(t, cursor, "expected next cursor")
+
+		// вставка между запросами страниц попадает в конец и не сдвигает выборку
+		_, err = todoItemRepo.Create(userId, listId, todo.TodoItem{Title: "4"})
+		assert.NoError(t, err, "failed to create item 4")
+
+		secondPage, cursor, err := todoItemRepo.GetAll(userId, listId, todo.ItemFilter{}, todo.PageInput{Limit: 2, Cursor: cursor})
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, []int{3, 4}, []int{secondPage[0].Id, secondPage[1].Id}, "expected items after cursor")
+		assert.Empty(t, cursor, "expected no next cursor on last page")
+	})
+
+	t.Run("filters by done and text", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		for _, title := range []string{"Buy milk", "Buy 100% juice", "Call mom"} {
+			_, err := todoItemRepo.Create(userId, listId, todo.TodoItem{Title: title})
+			assert.NoError(t, err, "failed to create item")
+		}
+		done := true
+		assert.NoError(t, todoItemRepo.Update(userId, 1, todo.UpdateItemInput{Done: &done}), "failed to update item")
+
+		items, _, err := todoItemRepo.GetAll(userId, listId, todo.ItemFilter{Query: "buy"}, todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 2, "expected case-insensitive text match")
+
+		// % ищется буквально, а не как шаблон
+		items, _, err = todoItemRepo.GetAll(userId, listId, todo.ItemFilter{Query: "100%"}, todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 1, "expected literal match")
+
+		notDone := false
+		items, _, err = todoItemRepo.GetAll(userId, listId, todo.ItemFilter{Done: &notDone, Query: "buy"}, todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 1, "expected filters to be combined")
+		assert.Equal(t, "Buy 100% juice", items[0].Title, "expected undone item")
+	})
+
+	t.Run("filters by priority and tag", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		for _, item := range []todo.TodoItem{
+			{Title: "Report", Priority: todo.PriorityHigh, Tags: []string{"work", "urgent"}},
+			{Title: "Groceries", Priority: todo.PriorityHigh, Tags: []string{"home"}},
+			{Title: "Slides", Priority: todo.PriorityLow, Tags: []string{"work"}},
+			{Title: "Someday"},
+		} {
+			_, err := todoItemRepo.Create(userId, listId, item)
+			assert.NoError(t, err, "failed to create item")
+		}
+
+		high := todo.PriorityHigh
+		items, _, err := todoItemRepo.GetAll(userId, listId, todo.ItemFilter{Priority: &high}, todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 2, "expected high priority items")
+
+		items, _, err = todoItemRepo.GetAll(userId, listId, todo.ItemFilter{Tag: "work"}, todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 2, "expected items tagged work")
+
+		items, _, err = todoItemRepo.GetAll(userId, listId, todo.ItemFilter{Priority: &high, Tag: "work"}, todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 1, "expected filters to be combined")
+		assert.Equal(t, "Report", items[0].Title, "expected high priority work item")
+		assert.Equal(t, []string{"work", "urgent"}, []string(items[0].Tags), "expected tags in stored order")
+
+		items, _, err = todoItemRepo.GetAll(userId, listId, todo.ItemFilter{Tag: "wor"}, todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, items, "expected exact tag match only")
+	})
 }
 
 func TestTodoItemPostgres_GetById(t *testing.T) {
@@ -205,7 +310,7 @@ func TestTodoItemPostgres_GetById(t *testing.T) {
 		listId, _ := createTestList(t, todoListRepo, userId)
 
 		// создаем айтем
-		itemId, item := createTestItem(t, todoItemRepo, listId)
+		itemId, item := createTestItem(t, todoItemRepo, userId, listId)
 		assert.NoError(t, err, "failed to create item")
 		assert.Equal(t, 1, itemId, "expected item ID=1")
 		assert.NotNil(t, item, "expected item to be not nil")
@@ -260,12 +365,12 @@ func TestTodoItemPostgres_Delete(t *testing.T) {
 		listId, _ := createTestList(t, todoListRepo, userId)
 
 		// создаем айтем
-		itemId, item := createTestItem(t, todoItemRepo, listId)
+		itemId, item := createTestItem(t, todoItemRepo, userId, listId)
 		assert.NoError(t, err, "failed to create item")
 		assert.Equal(t, 1, itemId, "expected item ID=1")
 		assert.NotNil(t, item, "expected item to be not nil")
 
-		err = todoItemRepo.Delete(userId, itemId)
+		err = todoItemRepo.Delete(userId, itemId, nil)
 		assert.NoError(t, err, "failed to delete item")
 		// Ожидаем что элемента нет
 		dbItem, err := todoItemRepo.GetById(userId, itemId)
@@ -286,7 +391,7 @@ func TestUpdateItem(t *testing.T) {
 		// Подготовка данных
 		userId := createTestUser(t, authRepo, db)
 		listId, _ := createTestList(t, todoListRepo, userId)
-		itemId, originalItem := createTestItem(t, todoItemRepo, listId)
+		itemId, originalItem := createTestItem(t, todoItemRepo, userId, listId)
 
 		// Обновление
 		newTitle := "Updated Title"
@@ -315,7 +420,7 @@ func TestUpdateItem(t *testing.T) {
 		// Подготовка данных
 		userId := createTestUser(t, authRepo, db)
 		listId, _ := createTestList(t, todoListRepo, userId)
-		itemId, originalItem := createTestItem(t, todoItemRepo, listId)
+		itemId, originalItem := createTestItem(t, todoItemRepo, userId, listId)
 
 		// Обновление
 		newDone := true
@@ -348,7 +453,7 @@ func TestUpdateItem(t *testing.T) {
 		// Подготовка данных
 		userId := createTestUser(t, authRepo, db)
 		listId, _ := createTestList(t, todoListRepo, userId)
-		itemId, originalItem := createTestItem(t, todoItemRepo, listId)
+		itemId, originalItem := createTestItem(t, todoItemRepo, userId, listId)
 
 		// Обновление
 		newTitle := "Updated Title"
@@ -374,6 +479,29 @@ func TestUpdateItem(t *testing.T) {
 		assert.NotEqual(t, dbItem.Title, originalItem.Title, "expected title to be updated")
 	})
 
+	t.Run("clear due date", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		dueAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		err = todoItemRepo.Update(userId, itemId, todo.UpdateItemInput{DueAt: &dueAt})
+		assert.NoError(t, err, "expected no error")
+
+		err = todoItemRepo.Update(userId, itemId, todo.UpdateItemInput{ClearDueAt: true})
+		assert.NoError(t, err, "expected no error")
+
+		dbItem, err := todoItemRepo.GetById(userId, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Nil(t, dbItem.DueAt, "expected due date to be cleared")
+	})
+
 	t.Run("no fields to update", func(t *testing.T) {
 		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
 		defer cleanup()
@@ -385,7 +513,7 @@ func TestUpdateItem(t *testing.T) {
 		// Подготовка данных
 		userId := createTestUser(t, authRepo, db)
 		listId, _ := createTestList(t, todoListRepo, userId)
-		itemId, _ := createTestItem(t, todoItemRepo, listId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
 
 		// Обновление
 		input := todo.UpdateItemInput{}
@@ -404,19 +532,19 @@ func TestUpdateItem(t *testing.T) {
 		// Подготовка данных
 		userId := createTestUser(t, authRepo, db)
 		listId, _ := createTestList(t, todoListRepo, userId)
-		itemId, originalItem := createTestItem(t, todoItemRepo, listId)
+		itemId, originalItem := createTestItem(t, todoItemRepo, userId, listId)
 
 		// Обновление с несуществующим userId
 		newTitle := "Updated Title"
 		input := todo.UpdateItemInput{Title: &newTitle}
 		err = todoItemRepo.Update(999, itemId, input)
-		assert.NoError(t, err, "expected no error, but no rows affected")
+		assert.ErrorIs(t, err, todo.ErrNotFound, "expected not found when no rows affected")
 
 		// // Проверка, что элемент не изменился
 
 		// Обновление с несуществующим itemId
 		err = todoItemRepo.Update(userId, 999, input)
-		assert.NoError(t, err, "expected no error, but no rows affected")
+		assert.ErrorIs(t, err, todo.ErrNotFound, "expected not found when no rows affected")
 
 		// Проверка, что элемент не изменился
 		dbItem, err := todoItemRepo.GetById(userId, itemId)
@@ -425,3 +553,205 @@ func TestUpdateItem(t *testing.T) {
 		
 	})
 }
+
+func TestTodoItemPostgres_Assign(t *testing.T) {
+	t.Run("assign and unassign on member removal", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		// Очистка таблиц
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		// Подготовка данных
+		userId := createTestUser(t, authRepo, db)
+		listId, list := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		// Назначение со старой версией отклоняется
+		staleVersion := 42
+		err = todoItemRepo.Assign(userId, itemId, &userId, &staleVersion)
+		assert.ErrorIs(t, err, todo.ErrPreconditionFailed, "expected version conflict")
+
+		// Назначаем исполнителя
+		err = todoItemRepo.Assign(userId, itemId, &userId, nil)
+		assert.NoError(t, err, "expected no error")
+
+		assigned, cursor, err := todoItemRepo.GetAssigned(userId, todo.PageInput{Limit: 1})
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, cursor, "expected single page")
+		assert.Len(t, assigned, 1, "expected one assigned item")
+		assert.Equal(t, itemId, assigned[0].Id, "expected assigned item")
+		assert.Equal(t, list.Title, assigned[0].ListTitle, "expected list title")
+
+		// Удаление участника из списка снимает назначение
+		_, err = db.Exec("DELETE FROM users_lists WHERE user_id=$1 AND list_id=$2", userId, listId)
+		assert.NoError(t, err, "failed to remove member")
+
+		var assigneeId *int
+		err = db.Get(&assigneeId, "SELECT assignee_id FROM todo_items WHERE id=$1", itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Nil(t, assigneeId, "expected item to be unassigned")
+	})
+}
+
+func TestTodoItemPostgres_GetAllForUser(t *testing.T) {
+	t.Run("returns items of all lists with filter and pagination", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		firstListId, err := todoListRepo.Create(userId, todo.TodoList{Title: "Home"})
+		assert.NoError(t, err, "failed to create list")
+		secondListId, err := todoListRepo.Create(userId, todo.TodoList{Title: "Work"})
+		assert.NoError(t, err, "failed to create list")
+		for _, listId := range []int{firstListId, secondListId, secondListId} {
+			_, err := todoItemRepo.Create(userId, listId, todo.TodoItem{Title: "Task"})
+			assert.NoError(t, err, "failed to create item")
+		}
+		done := true
+		assert.NoError(t, todoItemRepo.Update(userId, 3, todo.UpdateItemInput{Done: &done}), "failed to update item")
+
+		items, cursor, err := todoItemRepo.GetAllForUser(userId, todo.ItemFilter{}, nil, todo.PageInput{Limit: 2})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 2, "expected full page")
+		assert.Equal(t, "Home", items[0].ListTitle, "expected list title of first item")
+		assert.Equal(t, secondListId, items[1].ListId, "expected list id of second item")
+
+		items, cursor, err = todoItemRepo.GetAllForUser(userId, todo.ItemFilter{}, nil, todo.PageInput{Limit: 2, Cursor: cursor})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 1, "expected last item")
+		assert.Empty(t, cursor, "expected no next cursor")
+
+		notDone := false
+		items, _, err = todoItemRepo.GetAllForUser(userId, todo.ItemFilter{Done: &notDone}, nil, todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 2, "expected undone items only")
+	})
+
+	t.Run("compiles query into filters across lists", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, list := createTestList(t, todoListRepo, userId)
+
+		now := time.Now().UTC()
+		yesterday := now.Add(-24 * time.Hour)
+		_, err = todoItemRepo.Create(userId, listId, todo.TodoItem{Title: "Overdue report", DueAt: &yesterday})
+		assert.NoError(t, err, "failed to create item")
+		_, err = todoItemRepo.Create(userId, listId, todo.TodoItem{Title: "Someday report",
+			Priority: todo.PriorityHigh, Tags: []string{"work"}})
+		assert.NoError(t, err, "failed to create item")
+
+		parse := func(input string) query.Node {
+			expr, err := query.Parse(input, now)
+			assert.NoError(t, err, "failed to parse %q", input)
+			return expr
+		}
+
+		items, _, err := todoItemRepo.GetAllForUser(userId, todo.ItemFilter{}, parse("report due<today"), todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 1, "expected overdue item")
+		assert.Equal(t, list.Title, items[0].ListTitle, "expected list title")
+
+		// задача без срока не совпадает с due<today, значит попадает под отрицание
+		items, _, err = todoItemRepo.GetAllForUser(userId, todo.ItemFilter{}, parse("-due<today"), todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 1, "expected item without due date")
+		assert.Equal(t, "Someday report", items[0].Title, "expected item without due date")
+
+		items, _, err = todoItemRepo.GetAllForUser(userId, todo.ItemFilter{}, parse("done:true OR title:someday"), todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 1, "expected or to match")
+
+		items, _, err = todoItemRepo.GetAllForUser(userId, todo.ItemFilter{}, parse("done:false tag:work priority>=medium"), todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 1, "expected tag and priority to match")
+		assert.Equal(t, "Someday report", items[0].Title, "expected tagged item")
+
+		items, _, err = todoItemRepo.GetAllForUser(999, todo.ItemFilter{}, parse("report"), todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, items, "expected no items for non-member")
+	})
+}
+
+func TestTodoItemPostgres_Batch(t *testing.T) {
+	setup := func(t *testing.T) (*TodoItemPostgres, int, int, int, int) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		t.Cleanup(cleanup)
+
+		// Очистка таблиц
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+		otherListId, err := todoListRepo.Create(userId, todo.TodoList{Title: "Other"})
+		assert.NoError(t, err, "failed to create list")
+
+		return todoItemRepo, userId, listId, otherListId, itemId
+	}
+
+	done := true
+	stale := 5
+
+	t.Run("atomic batch rolls back on error", func(t *testing.T) {
+		todoItemRepo, userId, listId, _, itemId := setup(t)
+
+		results, err := todoItemRepo.Batch(userId, []todo.ItemOperation{
+			{Op: todo.ItemOpUpdate, Id: itemId, Changes: &todo.UpdateItemInput{Done: &done}},
+			{Op: todo.ItemOpCreate, ListId: listId, Item: &todo.TodoItem{Title: "New"}},
+			{Op: todo.ItemOpDelete, Id: 999},
+		}, true)
+
+		var opErr *todo.BatchOperationError
+		assert.ErrorAs(t, err, &opErr, "expected operation error")
+		assert.Equal(t, 2, opErr.Index, "expected failed operation index")
+		assert.ErrorIs(t, err, todo.ErrNotFound, "expected not found")
+		assert.Len(t, results, 3, "expected results up to the failed operation")
+
+		item, err := todoItemRepo.GetById(userId, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.False(t, item.Done, "expected update to be rolled back")
+
+		items, _, err := todoItemRepo.GetAll(userId, listId, todo.ItemFilter{}, todo.PageInput{})
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, items, 1, "expected create to be rolled back")
+	})
+
+	t.Run("best effort batch keeps successful operations", func(t *testing.T) {
+		todoItemRepo, userId, listId, otherListId, itemId := setup(t)
+
+		results, err := todoItemRepo.Batch(userId, []todo.ItemOperation{
+			{Op: todo.ItemOpUpdate, Id: itemId, Changes: &todo.UpdateItemInput{Done: &done}, Version: &stale},
+			{Op: todo.ItemOpCreate, ListId: listId, Item: &todo.TodoItem{Title: "New"}},
+			{Op: todo.ItemOpMove, Id: itemId, ListId: otherListId},
+		}, false)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, results, 3, "expected result for every operation")
+		assert.ErrorIs(t, results[0].Err, todo.ErrPreconditionFailed, "expected stale version to fail")
+		assert.NoError(t, results[1].Err, "expected create to succeed")
+		assert.NoError(t, results[2].Err, "expected move to succeed")
+
+		movedListId, err := todoItemRepo.GetListId(itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, otherListId, movedListId, "expected item to be moved")
+
+		item, err := todoItemRepo.GetById(userId, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.False(t, item.Done, "expected failed update not to apply")
+		assert.Equal(t, 2, item.Version, "expected move to bump version")
+
+		created, err := todoItemRepo.GetById(userId, results[1].Id)
+		assert.NoError(t, err, "expected created item")
+		assert.Equal(t, "New", created.Title, "unexpected created item")
+	})
+}
@@ -38,41 +38,106 @@ func (r *TodoListPostgres) Create(userId int, list todo.TodoList) (int, error) {
 		return 0, err
 	}
 
+	if err := addEvent(tx, todo.EventListCreated, userId, id, 0); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := addListHistory(tx, userId, id, todo.HistoryActionCreate, created(listFields(list))); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
 	return id, tx.Commit()
 }
 
-func (r *TodoListPostgres) GetAll(userId int) ([]todo.TodoList, error) {
+func (r *TodoListPostgres) GetAll(userId int, page todo.PageInput) ([]todo.TodoList, string, error) {
 	var lists []todo.TodoList
 
-	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description
+	afterId, err := page.AfterId()
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description, tl.version
 												FROM %s tl INNER JOIN %s ul on tl.id = ul.list_id
-												WHERE ul.user_id = $1`,
+												WHERE ul.user_id = $1 AND tl.deleted_at IS NULL AND tl.id > $2
+												ORDER BY tl.id LIMIT $3`,
 		todoListsTable, usersListsTable)
-	err := r.db.Select(&lists, query, userId)
+	if err := r.db.Select(&lists, query, userId, afterId, page.Size()+1); err != nil {
+		return nil, "", err
+	}
+
+	lists, nextCursor := nextPage(lists, page.Size(), func(l todo.TodoList) int { return l.Id })
 
-	return lists, err
+	return lists, nextCursor, nil
 }
 
 func (r *TodoListPostgres) GetById(userId, listId int) (todo.TodoList, error) {
+	return selectList(r.db, userId, listId, "")
+}
+
+// lockList читает список и блокирует его строку до конца транзакции, чтобы
+// снимок для истории оставался верным до изменения.
+func lockList(db sqlx.Queryer, userId, listId int) (todo.TodoList, error) {
+	return selectList(db, userId, listId, "FOR UPDATE OF tl")
+}
+
+func selectList(db sqlx.Queryer, userId, listId int, lock string) (todo.TodoList, error) {
 	var list todo.TodoList
 
-	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description 
+	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description, tl.version
 												FROM %s tl INNER JOIN %s ul on tl.id = ul.list_id 
-												WHERE ul.user_id = $1 AND ul.list_id = $2`,
-		todoListsTable, usersListsTable)
-	err := r.db.Get(&list, query, userId, listId)
+												WHERE ul.user_id = $1 AND ul.list_id = $2 AND tl.deleted_at IS NULL
+												%s`,
+		todoListsTable, usersListsTable, lock)
+	err := sqlx.Get(db, &list, query, userId, listId)
+
+	return list, dbError(err, "list")
+}
 
-	return list, err
+func addListHistory(db sqlx.Execer, userId, listId int, action string, changes todo.FieldChanges) error {
+	return addHistory(db, userId, todo.HistoryEntityList, listId, action, changes)
 }
 
-func (r *TodoListPostgres) Delete(userId, listId int) error {
-	query := fmt.Sprintf(`DELETE FROM %s tl USING %s ul 
-												WHERE tl.id = ul.list_id AND ul.user_id = $1 AND ul.list_id = $2`,
+// Delete переносит список в корзину. version - ожидаемая версия списка, nil -
+// без проверки.
+func (r *TodoListPostgres) Delete(userId, listId int, version *int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	list, err := lockList(tx, userId, listId)
+	if err != nil {
+		return err
+	}
+
+	// список уходит в корзину, окончательно его удаляет фоновая очистка
+	query := fmt.Sprintf(`UPDATE %s tl SET deleted_at = now()
+												FROM %s ul
+												WHERE tl.id = ul.list_id AND ul.user_id = $1 AND ul.list_id = $2 AND tl.deleted_at IS NULL
+													AND ($3::int IS NULL OR tl.version = $3)`,
 		todoListsTable, usersListsTable)
 
-	_, err := r.db.Exec(query, userId, listId)
+	err = execAffected(tx, "list", query, userId, listId, version)
+	if err != nil {
+		return versionError(err, "list", version, func() error {
+			_, err := r.GetById(userId, listId)
+			return err
+		})
+	}
+
+	if err := addEvent(tx, todo.EventListDeleted, userId, listId, 0); err != nil {
+		return err
+	}
+
+	if err := addListHistory(tx, userId, listId, todo.HistoryActionDelete, deleted(listFields(list))); err != nil {
+		return err
+	}
 
-	return err
+	return tx.Commit()
 }
 
 func (r *TodoListPostgres) Update(userId, listId int, input todo.UpdateListInput) error {
@@ -96,16 +161,55 @@ func (r *TodoListPostgres) Update(userId, listId int, input todo.UpdateListInput
 	// description=$1
 	// title=$1, description=$2
 	setQuery := strings.Join(setValues, ", ")
-	query := fmt.Sprintf(`UPDATE %s tl SET %s
+	query := fmt.Sprintf(`UPDATE %s tl SET %s, version = tl.version + 1
 												FROM %s ul
-												WHERE tl.id = ul.list_id AND ul.list_id = $%d AND ul.user_id = $%d`,
-		todoListsTable, setQuery, usersListsTable, argId, argId+1)
-	args = append(args, listId, userId)
+												WHERE tl.id = ul.list_id AND ul.list_id = $%d AND ul.user_id = $%d AND tl.deleted_at IS NULL
+													AND ($%d::int IS NULL OR tl.version = $%d)`,
+		todoListsTable, setQuery, usersListsTable, argId, argId+1, argId+2, argId+2)
+	args = append(args, listId, userId, input.Version)
 
 	logrus.Debugf("updateQuery: %s", query)
 	logrus.Debugf("args: %s", args)
 
-	_, err := r.db.Exec(query, args...)
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockList(tx, userId, listId)
+	if err != nil {
+		return err
+	}
+
+	err = execAffected(tx, "list", query, args...)
+	if err != nil {
+		return versionError(err, "list", input.Version, func() error {
+			_, err := r.GetById(userId, listId)
+			return err
+		})
+	}
+
+	if err := addEvent(tx, todo.EventListUpdated, userId, listId, 0); err != nil {
+		return err
+	}
+
+	after, err := selectList(tx, userId, listId, "")
+	if err != nil {
+		return err
+	}
+
+	if err := addListHistory(tx, userId, listId, todo.HistoryActionUpdate, diff(listFields(before), listFields(after))); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TodoListPostgres) GetUserIds(listId int) ([]int, error) {
+	userIds := make([]int, 0)
+	query := fmt.Sprintf("SELECT user_id FROM %s WHERE list_id = $1 ORDER BY id", usersListsTable)
+	err := r.db.Select(&userIds, query, listId)
 
-	return err
+	return userIds, err
 }
@@ -74,7 +74,7 @@ func TestTodoListPostgres_Create(t *testing.T) {
 
 		// Проверяем запись в todo_lists
 		var dbList todo.TodoList
-		err = db.Get(&dbList, "SELECT id, title, description FROM todo_lists WHERE id=$1", listId)
+		err = db.Get(&dbList, "SELECT id, title, description, version FROM todo_lists WHERE id=$1", listId)
 		assert.NoError(t, err, "failed to fetch list")
 		assert.Equal(t, list.Title, dbList.Title, "title mismatch")
 		assert.Equal(t, list.Description, dbList.Description, "description mismatch")
@@ -155,7 +155,7 @@ func TestTodoListPostgres_GetAll(t *testing.T) {
 			assert.Equal(t, idx+1, listId, "expected list ID=1")
 		}
 
-		toCompareList, err := todoListRepo.GetAll(userId)
+		toCompareList, _, err := todoListRepo.GetAll(userId, todo.PageInput{})
 		assert.NoError(t, err, "expected no error")
 		assert.Equal(t, len(lists), len(toCompareList), "have to be equal")
 	})
@@ -170,7 +170,7 @@ func TestTodoListPostgres_GetAll(t *testing.T) {
 
 		// Проверяем GetAll для несуществующего userId
 		randomUserId := 123
-		lists, err := todoListRepo.GetAll(randomUserId)
+		lists, _, err := todoListRepo.GetAll(randomUserId, todo.PageInput{})
 		assert.NoError(t, err, "expected no error")
 		assert.Empty(t, lists, "expected empty list")
 	})
@@ -256,7 +256,7 @@ func TestTodoListPostgres_Delete(t *testing.T) {
 		assert.Equal(t, 1, listId, "expected list ID=1")
 
 		// удаляем список
-		err = todoListRepo.Delete(userId, listId)
+		err = todoListRepo.Delete(userId, listId, nil)
 		assert.NoError(t, err, "expected no error")
 
 		// проверяем, что список удален
@@ -289,6 +289,7 @@ func TestTodoListPostgres_Update(t *testing.T) {
 		// Проверка
 		expectedList := originalList
 		expectedList.Title = newTitle
+		expectedList.Version = 2
 		checkList(t, db, listId, expectedList)
 	})
 
@@ -315,6 +316,7 @@ func TestTodoListPostgres_Update(t *testing.T) {
 		// Проверка
 		expectedList := originalList
 		expectedList.Description = newDescription
+		expectedList.Version = 2
 		checkList(t, db, listId, expectedList)
 	})
 
@@ -344,6 +346,7 @@ func TestTodoListPostgres_Update(t *testing.T) {
 		expectedList := originalList
 		expectedList.Title = newTitle
 		expectedList.Description = newDescription
+		expectedList.Version = 2
 		checkList(t, db, listId, expectedList)
 	})
 
@@ -385,18 +388,50 @@ func TestTodoListPostgres_Update(t *testing.T) {
 		newTitle := "Updated Title"
 		input := todo.UpdateListInput{Title: &newTitle}
 		err = todoListRepo.Update(999, listId, input)
-		assert.NoError(t, err, "expected no error, but no rows affected")
+		assert.ErrorIs(t, err, todo.ErrNotFound, "expected not found when no rows affected")
 
 		// Проверка, что список не изменился
 		checkList(t, db, listId, originalList)
 
 		// Обновление с несуществующим listId
 		err = todoListRepo.Update(userId, 999, input)
-		assert.NoError(t, err, "expected no error, but no rows affected")
+		assert.ErrorIs(t, err, todo.ErrNotFound, "expected not found when no rows affected")
 
 		// Проверка, что список не изменился
 		checkList(t, db, listId, originalList)
 	})
+
+	t.Run("version mismatch", func(t *testing.T) {
+		db, todoListRepo, _, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		// Очистка таблиц
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		// Подготовка данных
+		userId := createTestUser(t, authRepo, db)
+		listId, originalList := createTestList(t, todoListRepo, userId)
+
+		// Обновление с устаревшей версией
+		newTitle := "Updated Title"
+		staleVersion := 2
+		input := todo.UpdateListInput{Title: &newTitle, Version: &staleVersion}
+		err = todoListRepo.Update(userId, listId, input)
+		assert.ErrorIs(t, err, todo.ErrPreconditionFailed, "expected precondition failed")
+
+		// Проверка, что список не изменился
+		checkList(t, db, listId, originalList)
+
+		// Обновление с актуальной версией
+		input.Version = &originalList.Version
+		assert.NoError(t, todoListRepo.Update(userId, listId, input), "expected no error")
+
+		expectedList := originalList
+		expectedList.Title = newTitle
+		expectedList.Version = 2
+		checkList(t, db, listId, expectedList)
+	})
 }
 
 
@@ -447,25 +482,28 @@ func createTestList(t *testing.T, todoListRepo *TodoListPostgres, userId int) (i
 	assert.NoError(t, err, "failed to create list")
 	assert.Equal(t, 1, listId, "expected list ID=1")
 	list.Id = listId
+	list.Version = 1
 	return listId, list
 }
 
-func createTestItem(t *testing.T, todoItemRepo *TodoItemPostgres, listId int) (int, todo.TodoItem) {
+func createTestItem(t *testing.T, todoItemRepo *TodoItemPostgres, userId, listId int) (int, todo.TodoItem) {
 	item := todo.TodoItem{
 		Title:       "Important",
 		Description: "Make something important",
 		Done: false,
+		Tags:        []string{},
 	}
-	itemId, err := todoItemRepo.Create(listId, item)
+	itemId, err := todoItemRepo.Create(userId, listId, item)
 	assert.NoError(t, err, "failed to create item")
 	assert.Equal(t, 1, itemId, "expected list ID=1")
 	item.Id = itemId
+	item.Version = 1
 	return itemId, item
 }
 
 func checkList(t *testing.T, db *sqlx.DB, listId int, expected todo.TodoList) {
 	var dbList todo.TodoList
-	err := db.Get(&dbList, "SELECT id, title, description FROM todo_lists WHERE id=$1", listId)
+	err := db.Get(&dbList, "SELECT id, title, description, version FROM todo_lists WHERE id=$1", listId)
 	assert.NoError(t, err, "failed to fetch list")
 	assert.Equal(t, expected, dbList, "list mismatch")
 }
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var errNotInTrash = todo.NewError(todo.ErrNotFound, "not found in trash")
+
+type TrashPostgres struct {
+	db *sqlx.DB
+}
+
+func NewTrashPostgres(db *sqlx.DB) *TrashPostgres {
+	return &TrashPostgres{db: db}
+}
+
+func (r *TrashPostgres) GetAll(userId int) (todo.Trash, error) {
+	trash := todo.Trash{
+		Lists: make([]todo.TrashedList, 0),
+		Items: make([]todo.TrashedItem, 0),
+	}
+
+	listsQuery := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description, tl.version, tl.deleted_at
+												FROM %s tl INNER JOIN %s ul on tl.id = ul.list_id
+												WHERE ul.user_id = $1 AND tl.deleted_at IS NOT NULL
+												ORDER BY tl.deleted_at DESC`,
+		todoListsTable, usersListsTable)
+	if err := r.db.Select(&trash.Lists, listsQuery, userId); err != nil {
+		return trash, err
+	}
+
+	// задачи удаленного списка восстанавливаются вместе с ним, поэтому здесь их нет
+	itemsQuery := fmt.Sprintf(`SELECT ti.id, ti.title, ti.description, ti.done, ti.due_at, ti.assignee_id, ti.priority, ti.tags, ti.version, li.list_id, ti.deleted_at
+												FROM %s ti
+												JOIN %s li ON li.item_id = ti.id
+												JOIN %s ul ON ul.list_id = li.list_id
+												JOIN %s tl ON tl.id = li.list_id
+												WHERE ul.user_id = $1 AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL
+												ORDER BY ti.deleted_at DESC`,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable)
+	if err := r.db.Select(&trash.Items, itemsQuery, userId); err != nil {
+		return trash, err
+	}
+
+	return trash, nil
+}
+
+func (r *TrashPostgres) RestoreList(userId, listId int) error {
+	query := fmt.Sprintf(`UPDATE %s tl SET deleted_at = NULL, version = tl.version + 1
+												FROM %s ul
+												WHERE tl.id = ul.list_id AND ul.user_id = $1 AND tl.id = $2 AND tl.deleted_at IS NOT NULL`,
+		todoListsTable, usersListsTable)
+
+	return r.restore(query, userId, listId, func(tx *sqlx.Tx) error {
+		list, err := selectList(tx, userId, listId, "")
+		if err != nil {
+			return err
+		}
+
+		if err := addEvent(tx, todo.EventListCreated, userId, listId, 0); err != nil {
+			return err
+		}
+
+		return addListHistory(tx, userId, listId, todo.HistoryActionRestore, created(listFields(list)))
+	})
+}
+
+// RestoreItem возвращает задачу из корзины и отдает id ее списка.
+func (r *TrashPostgres) RestoreItem(userId, itemId int) (int, error) {
+	query := fmt.Sprintf(`UPDATE %s ti SET deleted_at = NULL, version = ti.version + 1
+												FROM %s li, %s ul, %s tl
+												WHERE ti.id = li.item_id AND li.list_id = ul.list_id AND tl.id = li.list_id
+													AND ul.user_id = $1 AND ti.id = $2 AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL`,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable)
+
+	var listId int
+	err := r.restore(query, userId, itemId, func(tx *sqlx.Tx) error {
+		item, err := getItem(tx, userId, itemId)
+		if err != nil {
+			return err
+		}
+
+		if listId, err = itemListId(tx, itemId); err != nil {
+			return err
+		}
+
+		if err := addEvent(tx, todo.EventItemCreated, userId, listId, itemId); err != nil {
+			return err
+		}
+
+		return addItemHistory(tx, userId, itemId, todo.HistoryActionRestore, created(itemFields(item)))
+	})
+
+	return listId, err
+}
+
+// restore возвращает запись из корзины с новой версией, чтобы изменения по
+// версии до удаления отклонялись, и в той же транзакции вызывает record для
+// события и истории: для участников списка запись появляется заново.
+func (r *TrashPostgres) restore(query string, userId, id int, record func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, userId, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return errNotInTrash
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Purge окончательно удаляет списки и задачи, лежащие в корзине дольше before,
+// и возвращает ключи блобов вложений удаленных задач.
+func (r *TrashPostgres) Purge(before time.Time) ([]string, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var itemIds []int64
+	// задачи удаляемых списков; блокируем списки, чтобы их не восстановили посреди очистки
+	listItemsQuery := fmt.Sprintf(`SELECT li.item_id FROM %s li JOIN %s tl ON tl.id = li.list_id
+												WHERE tl.deleted_at < $1 FOR UPDATE OF tl`,
+		listsItemsTable, todoListsTable)
+	if err := tx.Select(&itemIds, listItemsQuery, before); err != nil {
+		return nil, err
+	}
+
+	var trashedItemIds []int64
+	itemsQuery := fmt.Sprintf("SELECT id FROM %s WHERE deleted_at < $1 FOR UPDATE", todoItemsTable)
+	if err := tx.Select(&trashedItemIds, itemsQuery, before); err != nil {
+		return nil, err
+	}
+	itemIds = append(itemIds, trashedItemIds...)
+
+	keys := make([]string, 0)
+	attachmentsQuery := fmt.Sprintf("DELETE FROM %s WHERE item_id = ANY($1) RETURNING storage_key", attachmentsTable)
+	if err := tx.Select(&keys, attachmentsQuery, pq.Array(itemIds)); err != nil {
+		return nil, err
+	}
+
+	deleteItemsQuery := fmt.Sprintf("DELETE FROM %s WHERE id = ANY($1)", todoItemsTable)
+	if _, err := tx.Exec(deleteItemsQuery, pq.Array(itemIds)); err != nil {
+		return nil, err
+	}
+
+	deleteListsQuery := fmt.Sprintf("DELETE FROM %s WHERE deleted_at < $1", todoListsTable)
+	if _, err := tx.Exec(deleteListsQuery, before); err != nil {
+		return nil, err
+	}
+
+	return keys, tx.Commit()
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrashPostgres_Restore(t *testing.T) {
+	t.Run("deleted item goes to trash and is restored", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, item := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewTrashPostgres(db)
+		assert.NoError(t, todoItemRepo.Delete(userId, itemId, nil), "failed to delete item")
+
+		trash, err := repo.GetAll(userId)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, trash.Items, 1, "expected one item in trash")
+		assert.Equal(t, itemId, trash.Items[0].Id, "expected deleted item in trash")
+
+		restoredListId, err := repo.RestoreItem(userId, itemId)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, listId, restoredListId, "expected item list to be returned")
+		dbItem, err := todoItemRepo.GetById(userId, itemId)
+		assert.NoError(t, err, "expected item to be restored")
+		// восстановление меняет версию, чтобы старый ETag не прошел If-Match
+		item.Version++
+		assert.Equal(t, item, dbItem, "expected restored item to match")
+
+		var action string
+		assert.NoError(t, db.Get(&action, "SELECT action FROM history WHERE entity_type = 'item' AND entity_id = $1 ORDER BY id DESC LIMIT 1", itemId), "expected no error")
+		assert.Equal(t, todo.HistoryActionRestore, action, "expected restore in history")
+
+		// повторное восстановление - задачи в корзине уже нет
+		_, err = repo.RestoreItem(userId, itemId)
+		assert.Error(t, err, "expected error for item not in trash")
+	})
+
+	t.Run("items of deleted list are hidden until list restore", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewTrashPostgres(db)
+		assert.NoError(t, todoListRepo.Delete(userId, listId, nil), "failed to delete list")
+
+		_, err = todoItemRepo.GetById(userId, itemId)
+		assert.Error(t, err, "expected item of deleted list to be hidden")
+
+		trash, err := repo.GetAll(userId)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, trash.Lists, 1, "expected one list in trash")
+		assert.Empty(t, trash.Items, "expected items of deleted list not to be listed separately")
+
+		assert.NoError(t, repo.RestoreList(userId, listId), "expected no error")
+		_, err = todoItemRepo.GetById(userId, itemId)
+		assert.NoError(t, err, "expected item to be visible after list restore")
+	})
+}
+
+func TestTrashPostgres_Purge(t *testing.T) {
+	t.Run("purges expired lists with their items", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, _ := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewTrashPostgres(db)
+		assert.NoError(t, todoListRepo.Delete(userId, listId, nil), "failed to delete list")
+
+		// срок хранения еще не истек
+		_, err = repo.Purge(time.Now().Add(-time.Hour))
+		assert.NoError(t, err, "expected no error")
+		var count int
+		assert.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM todo_lists WHERE id=$1", listId))
+		assert.Equal(t, 1, count, "expected list to be kept")
+
+		_, err = repo.Purge(time.Now().Add(time.Hour))
+		assert.NoError(t, err, "expected no error")
+		assert.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM todo_lists WHERE id=$1", listId))
+		assert.Equal(t, 0, count, "expected list to be purged")
+		assert.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM todo_items WHERE id=$1", itemId))
+		assert.Equal(t, 0, count, "expected item to be purged with list")
+	})
+}
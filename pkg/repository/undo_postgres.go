@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+)
+
+var errUndoTokenNotFound = todo.NewError(todo.ErrNotFound, "undo token not found or expired")
+
+type UndoPostgres struct {
+	db *sqlx.DB
+}
+
+func NewUndoPostgres(db *sqlx.DB) *UndoPostgres {
+	return &UndoPostgres{db: db}
+}
+
+func (r *UndoPostgres) Create(userId int, token string, ops []todo.UndoOperation, expiresAt time.Time) error {
+	operations, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (token, user_id, operations, expires_at) VALUES ($1, $2, $3, $4)", undoTokensTable)
+	_, err = r.db.Exec(query, token, userId, operations, expiresAt)
+
+	return err
+}
+
+// Apply погашает токен и откатывает сохраненные операции в одной транзакции,
+// поэтому токен нельзя применить дважды. Возвращает id затронутых списков и
+// задач, чтобы сбросить кэш у всех участников.
+func (r *UndoPostgres) Apply(userId int, token string) ([]int, []int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var operations []byte
+	query := fmt.Sprintf("DELETE FROM %s WHERE token = $1 AND user_id = $2 AND expires_at > now() RETURNING operations", undoTokensTable)
+	if err := tx.Get(&operations, query, token, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, errUndoTokenNotFound
+		}
+		return nil, nil, err
+	}
+
+	var ops []todo.UndoOperation
+	if err := json.Unmarshal(operations, &ops); err != nil {
+		return nil, nil, err
+	}
+
+	listIds, itemIds := make([]int, 0), make([]int, 0)
+	// откатываем в обратном порядке, как при раскрутке стека
+	for i := len(ops) - 1; i >= 0; i-- {
+		if err := applyUndoOperation(tx, userId, ops[i]); err != nil {
+			return nil, nil, err
+		}
+
+		if ops[i].Entity == todo.UndoEntityList {
+			listIds = append(listIds, ops[i].List.Id)
+		} else {
+			itemIds = append(itemIds, ops[i].Item.Id)
+		}
+	}
+
+	return listIds, itemIds, tx.Commit()
+}
+
+func (r *UndoPostgres) DeleteExpired() (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE expires_at <= now()", undoTokensTable)
+	result, err := r.db.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// applyUndoOperation откатывает операцию от имени userId так же, как обычное
+// изменение: с проверкой доступа к списку и версии записи, событием и записью
+// истории. Возвращенная запись приходит как созданная, откаченная правка - как
+// изменение.
+func applyUndoOperation(tx *sqlx.Tx, userId int, op todo.UndoOperation) error {
+	switch {
+	case op.Entity == todo.UndoEntityList && op.List != nil:
+		return undoList(tx, userId, op)
+	case op.Entity == todo.UndoEntityItem && op.Item != nil:
+		return undoItem(tx, userId, op)
+	default:
+		return fmt.Errorf("invalid undo operation for %q", op.Entity)
+	}
+}
+
+// undoVersionError - запись доступна, но изменение не прошло: ее изменили
+// после операции, которую отменяют.
+func undoVersionError(err error, entity string, version *int) error {
+	return versionError(err, entity, version, func() error { return nil })
+}
+
+func undoList(tx *sqlx.Tx, userId int, op todo.UndoOperation) error {
+	list := op.List
+	if !op.Restore {
+		before, err := lockList(tx, userId, list.Id)
+		if err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf(`UPDATE %s SET title = $1, description = $2, version = version + 1
+													WHERE id = $3 AND ($4::int IS NULL OR version = $4)`, todoListsTable)
+		if err := execAffected(tx, "list", query, list.Title, list.Description, list.Id, op.Version); err != nil {
+			return undoVersionError(err, "list", op.Version)
+		}
+
+		if err := addEvent(tx, todo.EventListUpdated, userId, list.Id, 0); err != nil {
+			return err
+		}
+
+		after, err := selectList(tx, userId, list.Id, "")
+		if err != nil {
+			return err
+		}
+
+		return addListHistory(tx, userId, list.Id, todo.HistoryActionUpdate, diff(listFields(before), listFields(after)))
+	}
+
+	if err := checkUndoMember(tx, userId, list.Id, op.UserIds); err != nil {
+		return err
+	}
+
+	// строка могла быть уже вычищена из корзины - тогда создаем ее заново с тем же id
+	query := fmt.Sprintf(`INSERT INTO %s AS tl (id, title, description, version) VALUES ($1, $2, $3, $4::int + 1)
+												ON CONFLICT (id) DO UPDATE SET title = EXCLUDED.title, description = EXCLUDED.description,
+													deleted_at = NULL, version = tl.version + 1
+												WHERE tl.deleted_at IS NOT NULL AND ($5::int IS NULL OR tl.version = $5)`,
+		todoListsTable)
+	if err := execAffected(tx, "list", query, list.Id, list.Title, list.Description, list.Version, op.Version); err != nil {
+		return undoVersionError(err, "list", op.Version)
+	}
+
+	linkQuery := fmt.Sprintf(`INSERT INTO %[1]s (user_id, list_id) SELECT $1::int, $2::int
+												WHERE NOT EXISTS (SELECT 1 FROM %[1]s WHERE user_id = $1 AND list_id = $2)`,
+		usersListsTable)
+	for _, memberId := range op.UserIds {
+		if _, err := tx.Exec(linkQuery, memberId, list.Id); err != nil {
+			return err
+		}
+	}
+
+	if err := addEvent(tx, todo.EventListCreated, userId, list.Id, 0); err != nil {
+		return err
+	}
+
+	return addListHistory(tx, userId, list.Id, todo.HistoryActionRestore, created(listFields(*list)))
+}
+
+// checkUndoMember проверяет, что пользователь по-прежнему участник списка.
+// Список, уже вычищенный из корзины, проверяется по участникам из снимка.
+func checkUndoMember(db sqlx.Queryer, userId, listId int, snapshotUserIds []int) error {
+	var exists, member bool
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE id = $2),
+													EXISTS (SELECT 1 FROM %s WHERE user_id = $1 AND list_id = $2)`,
+		todoListsTable, usersListsTable)
+	if err := db.QueryRowx(query, userId, listId).Scan(&exists, &member); err != nil {
+		return err
+	}
+
+	if !exists {
+		for _, memberId := range snapshotUserIds {
+			member = member || memberId == userId
+		}
+	}
+
+	if !member {
+		return todo.NewError(todo.ErrNotFound, "list not found")
+	}
+
+	return nil
+}
+
+func undoItem(tx *sqlx.Tx, userId int, op todo.UndoOperation) error {
+	item := op.Item
+	if !op.Restore {
+		before, err := lockItem(tx, userId, item.Id)
+		if err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf(`UPDATE %s SET title = $1, description = $2, done = $3, due_at = $4, assignee_id = $5,
+													priority = $6, tags = COALESCE($7::text[], '{}'), version = version + 1
+												WHERE id = $8 AND ($9::int IS NULL OR version = $9)`, todoItemsTable)
+		err = execAffected(tx, "item", query, item.Title, item.Description, item.Done, item.DueAt, item.AssigneeId,
+			item.Priority, item.Tags, item.Id, op.Version)
+		if err != nil {
+			return undoVersionError(err, "item", op.Version)
+		}
+
+		if err := addItemEvent(tx, todo.EventItemUpdated, userId, item.Id); err != nil {
+			return err
+		}
+
+		return addItemUpdateHistory(tx, userId, before)
+	}
+
+	// задача возвращается только в список, в котором пользователь состоит
+	if _, err := selectList(tx, userId, op.ListId, ""); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s AS ti (id, title, description, done, due_at, assignee_id, priority, tags, version)
+												VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8::text[], '{}'), $9::int + 1)
+												ON CONFLICT (id) DO UPDATE SET title = EXCLUDED.title, description = EXCLUDED.description,
+													done = EXCLUDED.done, due_at = EXCLUDED.due_at, assignee_id = EXCLUDED.assignee_id,
+													priority = EXCLUDED.priority, tags = EXCLUDED.tags, deleted_at = NULL, version = ti.version + 1
+												WHERE ti.deleted_at IS NOT NULL AND ($10::int IS NULL OR ti.version = $10)`,
+		todoItemsTable)
+	err := execAffected(tx, "item", query, item.Id, item.Title, item.Description, item.Done, item.DueAt, item.AssigneeId,
+		item.Priority, item.Tags, item.Version, op.Version)
+	if err != nil {
+		return undoVersionError(err, "item", op.Version)
+	}
+
+	linkQuery := fmt.Sprintf(`INSERT INTO %[1]s (list_id, item_id) SELECT $1::int, $2::int
+												WHERE NOT EXISTS (SELECT 1 FROM %[1]s WHERE list_id = $1 AND item_id = $2)`,
+		listsItemsTable)
+	if _, err := tx.Exec(linkQuery, op.ListId, item.Id); err != nil {
+		return err
+	}
+
+	if err := addEvent(tx, todo.EventItemCreated, userId, op.ListId, item.Id); err != nil {
+		return err
+	}
+
+	return addItemHistory(tx, userId, item.Id, todo.HistoryActionRestore, created(itemFields(*item)))
+}
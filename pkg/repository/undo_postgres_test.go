@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndoPostgres_Apply(t *testing.T) {
+	t.Run("undo item delete restores item once", func(t *testing.T) {
+		db, todoListRepo, todoItemRepo, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items, undo_tokens RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, _ := createTestList(t, todoListRepo, userId)
+		itemId, item := createTestItem(t, todoItemRepo, userId, listId)
+
+		repo := NewUndoPostgres(db)
+		assert.NoError(t, todoItemRepo.Delete(userId, itemId, nil), "failed to delete item")
+		ops := []todo.UndoOperation{{Entity: todo.UndoEntityItem, Restore: true, Item: &item, ListId: listId}}
+		assert.NoError(t, repo.Create(userId, "token", ops, time.Now().Add(time.Minute)), "expected no error")
+
+		// чужой токен не применяется
+		_, _, err = repo.Apply(999, "token")
+		assert.Error(t, err, "expected error for another user")
+
+		listIds, itemIds, err := repo.Apply(userId, "token")
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, listIds, "expected no lists to be affected")
+		assert.Equal(t, []int{itemId}, itemIds, "expected restored item to be reported")
+		dbItem, err := todoItemRepo.GetById(userId, itemId)
+		assert.NoError(t, err, "expected item to be restored")
+		// возвращенная задача получает новую версию
+		item.Version++
+		assert.Equal(t, item, dbItem, "expected restored item to match")
+
+		_, _, err = repo.Apply(userId, "token")
+		assert.Error(t, err, "expected token to be consumed")
+	})
+
+	t.Run("undo list update reverts fields", func(t *testing.T) {
+		db, todoListRepo, _, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items, undo_tokens RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, list := createTestList(t, todoListRepo, userId)
+
+		repo := NewUndoPostgres(db)
+		title := "Renamed"
+		assert.NoError(t, todoListRepo.Update(userId, listId, todo.UpdateListInput{Title: &title}), "failed to update list")
+		ops := []todo.UndoOperation{{Entity: todo.UndoEntityList, List: &list}}
+		assert.NoError(t, repo.Create(userId, "token", ops, time.Now().Add(time.Minute)), "expected no error")
+
+		listIds, itemIds, err := repo.Apply(userId, "token")
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, []int{listId}, listIds, "expected reverted list to be reported")
+		assert.Empty(t, itemIds, "expected no items to be affected")
+		dbList, err := todoListRepo.GetById(userId, listId)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, list.Title, dbList.Title, "expected title to be reverted")
+	})
+
+	t.Run("undo is rejected after a later change", func(t *testing.T) {
+		db, todoListRepo, _, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items, undo_tokens, history RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, list := createTestList(t, todoListRepo, userId)
+
+		repo := NewUndoPostgres(db)
+		title := "Renamed"
+		assert.NoError(t, todoListRepo.Update(userId, listId, todo.UpdateListInput{Title: &title}), "failed to update list")
+		changed := list.Version + 1
+		ops := []todo.UndoOperation{{Entity: todo.UndoEntityList, List: &list, Version: &changed}}
+		assert.NoError(t, repo.Create(userId, "stale", ops, time.Now().Add(time.Minute)), "expected no error")
+		assert.NoError(t, repo.Create(userId, "fresh", ops, time.Now().Add(time.Minute)), "expected no error")
+
+		// правка соавтора после изменения
+		other := "Edited"
+		assert.NoError(t, todoListRepo.Update(userId, listId, todo.UpdateListInput{Title: &other}), "failed to update list")
+		_, _, err = repo.Apply(userId, "stale")
+		assert.ErrorIs(t, err, todo.ErrPreconditionFailed, "expected later change to be kept")
+
+		dbList, err := todoListRepo.GetById(userId, listId)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, other, dbList.Title, "expected later change to be kept")
+
+		// участник, которого убрали из списка, отменить изменение не может
+		_, err = db.Exec("DELETE FROM users_lists WHERE user_id = $1 AND list_id = $2", userId, listId)
+		assert.NoError(t, err, "failed to remove member")
+		_, _, err = repo.Apply(userId, "fresh")
+		assert.ErrorIs(t, err, todo.ErrNotFound, "expected removed member to be rejected")
+	})
+
+	t.Run("undo is recorded in history", func(t *testing.T) {
+		db, todoListRepo, _, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items, undo_tokens, history RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		listId, list := createTestList(t, todoListRepo, userId)
+
+		repo := NewUndoPostgres(db)
+		title := "Renamed"
+		assert.NoError(t, todoListRepo.Update(userId, listId, todo.UpdateListInput{Title: &title}), "failed to update list")
+		changed := list.Version + 1
+		ops := []todo.UndoOperation{{Entity: todo.UndoEntityList, List: &list, Version: &changed}}
+		assert.NoError(t, repo.Create(userId, "token", ops, time.Now().Add(time.Minute)), "expected no error")
+		_, _, err = repo.Apply(userId, "token")
+		assert.NoError(t, err, "expected no error")
+
+		entries, err := NewHistoryPostgres(db).GetForList(userId, listId, 10, 0)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, entries, 3, "expected create, update and undo")
+		assert.Equal(t, todo.HistoryActionUpdate, entries[0].Action, "expected undo as update")
+		assert.Equal(t, list.Title, entries[0].Changes["title"].New, "expected title to be reverted")
+	})
+
+	t.Run("expired token is rejected and cleaned up", func(t *testing.T) {
+		db, todoListRepo, _, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, todo_lists, users_lists, todo_items, lists_items, undo_tokens RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		_, list := createTestList(t, todoListRepo, userId)
+
+		repo := NewUndoPostgres(db)
+		ops := []todo.UndoOperation{{Entity: todo.UndoEntityList, List: &list}}
+		assert.NoError(t, repo.Create(userId, "token", ops, time.Now().Add(-time.Minute)), "expected no error")
+
+		_, _, err = repo.Apply(userId, "token")
+		assert.Error(t, err, "expected error for expired token")
+
+		deleted, err := repo.DeleteExpired()
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, int64(1), deleted, "expected expired token to be deleted")
+	})
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type WebhookPostgres struct {
+	db *sqlx.DB
+}
+
+func NewWebhookPostgres(db *sqlx.DB) *WebhookPostgres {
+	return &WebhookPostgres{db: db}
+}
+
+func (r *WebhookPostgres) Create(userId int, webhook todo.Webhook) (int, error) {
+	events := webhook.Events
+	if events == nil {
+		events = pq.StringArray{}
+	}
+
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (user_id, url, secret, events) VALUES ($1, $2, $3, $4) RETURNING id", webhooksTable)
+	row := r.db.QueryRow(query, userId, webhook.URL, webhook.Secret, events)
+	if err := row.Scan(&id); err != nil {
+		return 0, dbError(err, "webhook")
+	}
+
+	return id, nil
+}
+
+func (r *WebhookPostgres) GetAll(userId int) ([]todo.Webhook, error) {
+	webhooks := make([]todo.Webhook, 0)
+	query := fmt.Sprintf(`SELECT id, user_id, url, events, active, failure_count, disabled_at, created_at
+												FROM %s WHERE user_id = $1 ORDER BY id`, webhooksTable)
+	err := r.db.Select(&webhooks, query, userId)
+
+	return webhooks, err
+}
+
+func (r *WebhookPostgres) GetById(userId, webhookId int) (todo.Webhook, error) {
+	var webhook todo.Webhook
+	query := fmt.Sprintf(`SELECT id, user_id, url, events, active, failure_count, disabled_at, created_at
+												FROM %s WHERE id = $1 AND user_id = $2`, webhooksTable)
+	err := r.db.Get(&webhook, query, webhookId, userId)
+
+	return webhook, dbError(err, "webhook")
+}
+
+// Update меняет подписку. Включение сбрасывает счетчик ошибок, выключение
+// запоминает время в disabled_at, как и автоматическое отключение.
+func (r *WebhookPostgres) Update(userId, webhookId int, input todo.UpdateWebhookInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.URL != nil {
+		setValues = append(setValues, fmt.Sprintf("url=$%d", argId))
+		args = append(args, *input.URL)
+		argId++
+	}
+
+	if input.Events != nil {
+		setValues = append(setValues, fmt.Sprintf("events=$%d", argId))
+		args = append(args, pq.StringArray(*input.Events))
+		argId++
+	}
+
+	if input.Active != nil {
+		if *input.Active {
+			setValues = append(setValues, "active=true", "failure_count=0", "disabled_at=NULL")
+		} else {
+			setValues = append(setValues, "active=false", "disabled_at=COALESCE(disabled_at, now())")
+		}
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d AND user_id = $%d",
+		webhooksTable, strings.Join(setValues, ", "), argId, argId+1)
+	args = append(args, webhookId, userId)
+
+	return execAffected(r.db, "webhook", query, args...)
+}
+
+func (r *WebhookPostgres) Delete(userId, webhookId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND user_id = $2", webhooksTable)
+
+	return execAffected(r.db, "webhook", query, webhookId, userId)
+}
+
+// GetDeliveries возвращает последние доставки вебхука, новые первыми.
+func (r *WebhookPostgres) GetDeliveries(webhookId, limit int) ([]todo.WebhookDelivery, error) {
+	deliveries := make([]todo.WebhookDelivery, 0)
+	query := fmt.Sprintf(`SELECT id, webhook_id, event_type, payload, status, attempts, response_status, last_error, created_at, delivered_at
+												FROM %s WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2`, webhookDeliveriesTable)
+	err := r.db.Select(&deliveries, query, webhookId, limit)
+
+	return deliveries, err
+}
+
+// Enqueue ставит событие в очередь всем включенным вебхукам пользователей,
+// подписанным на этот тип события.
+func (r *WebhookPostgres) Enqueue(userIds []int, eventType string, payload []byte) error {
+	query := fmt.Sprintf(`INSERT INTO %s (webhook_id, event_type, payload)
+												SELECT id, $1, $2 FROM %s
+												WHERE user_id = ANY($3) AND active AND (cardinality(events) = 0 OR $1 = ANY(events))`,
+		webhookDeliveriesTable, webhooksTable)
+	// []byte lib/pq передает как bytea, jsonb нужна строка
+	_, err := r.db.Exec(query, eventType, string(payload), pq.Array(userIds))
+
+	return err
+}
+
+// ClaimDue захватывает доставки, время которых пришло, так же как
+// ReminderPostgres.ClaimDue. Доставки отключенных вебхуков ждут включения.
+func (r *WebhookPostgres) ClaimDue(limit int, lease time.Duration) ([]todo.DueWebhookDelivery, error) {
+	var deliveries []todo.DueWebhookDelivery
+	query := fmt.Sprintf(`WITH due AS (
+													SELECT d.id FROM %[1]s d
+													JOIN %[2]s w ON w.id = d.webhook_id
+													WHERE d.status = $1 AND d.next_attempt_at <= now() AND w.active
+													ORDER BY d.next_attempt_at
+													LIMIT $2
+													FOR UPDATE OF d SKIP LOCKED
+												), claimed AS (
+													UPDATE %[1]s d SET attempts = d.attempts + 1, next_attempt_at = now() + $3::float8 * interval '1 second'
+													FROM due WHERE d.id = due.id
+													RETURNING d.*
+												)
+												SELECT c.id, c.webhook_id, c.event_type, c.payload, c.status, c.attempts, c.response_status,
+													c.last_error, c.created_at, c.delivered_at, w.url, w.secret
+												FROM claimed c JOIN %[2]s w ON w.id = c.webhook_id`,
+		webhookDeliveriesTable, webhooksTable)
+	err := r.db.Select(&deliveries, query, todo.WebhookDeliveryPending, limit, lease.Seconds())
+
+	return deliveries, err
+}
+
+// MarkDelivered отмечает доставку успешной и сбрасывает счетчик ошибок вебхука.
+func (r *WebhookPostgres) MarkDelivered(deliveryId, responseStatus int) error {
+	query := fmt.Sprintf(`WITH delivered AS (
+													UPDATE %s SET status = $1, response_status = $2, last_error = NULL, delivered_at = now()
+													WHERE id = $3 RETURNING webhook_id
+												)
+												UPDATE %s w SET failure_count = 0 FROM delivered WHERE w.id = delivered.webhook_id`,
+		webhookDeliveriesTable, webhooksTable)
+	_, err := r.db.Exec(query, todo.WebhookDeliveryDelivered, responseStatus, deliveryId)
+
+	return err
+}
+
+func (r *WebhookPostgres) MarkRetry(deliveryId int, nextAttemptAt time.Time, responseStatus *int, lastErr string) error {
+	query := fmt.Sprintf("UPDATE %s SET next_attempt_at = $1, response_status = $2, last_error = $3 WHERE id = $4", webhookDeliveriesTable)
+	_, err := r.db.Exec(query, nextAttemptAt, responseStatus, lastErr, deliveryId)
+
+	return err
+}
+
+func (r *WebhookPostgres) MarkFailed(deliveryId int, responseStatus *int, lastErr string) error {
+	query := fmt.Sprintf("UPDATE %s SET status = $1, response_status = $2, last_error = $3 WHERE id = $4", webhookDeliveriesTable)
+	_, err := r.db.Exec(query, todo.WebhookDeliveryFailed, responseStatus, lastErr, deliveryId)
+
+	return err
+}
+
+// RecordFailure увеличивает счетчик неудачных попыток подряд и отключает
+// вебхук, когда он достигает disableAfter. Возвращает true, если вебхук
+// отключен этим вызовом.
+func (r *WebhookPostgres) RecordFailure(webhookId, disableAfter int) (bool, error) {
+	var disabled bool
+	query := fmt.Sprintf(`UPDATE %s SET failure_count = failure_count + 1,
+													active = active AND failure_count + 1 < $1,
+													disabled_at = CASE WHEN active AND failure_count + 1 >= $1 THEN now() ELSE disabled_at END
+												WHERE id = $2
+												RETURNING COALESCE(disabled_at = now(), false)`, webhooksTable)
+	err := r.db.QueryRow(query, disableAfter, webhookId).Scan(&disabled)
+
+	return disabled, dbError(err, "webhook")
+}
+
+// DeleteDeliveriesBefore удаляет журнал завершенных доставок старше before.
+func (r *WebhookPostgres) DeleteDeliveriesBefore(before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE status <> $1 AND created_at < $2", webhookDeliveriesTable)
+	result, err := r.db.Exec(query, todo.WebhookDeliveryPending, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookPostgres_Enqueue(t *testing.T) {
+	t.Run("enqueues only for subscribed active webhooks", func(t *testing.T) {
+		db, _, _, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, webhooks, webhook_deliveries RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		repo := NewWebhookPostgres(db)
+
+		allId, err := repo.Create(userId, todo.Webhook{URL: "http://localhost/all", Secret: "s1"})
+		assert.NoError(t, err, "expected no error")
+		completedId, err := repo.Create(userId, todo.Webhook{URL: "http://localhost/done", Secret: "s2",
+			Events: pq.StringArray{todo.EventItemCompleted}})
+		assert.NoError(t, err, "expected no error")
+		inactive := false
+		disabledId, err := repo.Create(userId, todo.Webhook{URL: "http://localhost/off", Secret: "s3"})
+		assert.NoError(t, err, "expected no error")
+		assert.NoError(t, repo.Update(userId, disabledId, todo.UpdateWebhookInput{Active: &inactive}), "expected no error")
+
+		assert.NoError(t, repo.Enqueue([]int{userId}, todo.EventItemUpdated, []byte(`{"type":"item.updated"}`)), "expected no error")
+		assert.NoError(t, repo.Enqueue([]int{userId}, todo.EventItemCompleted, []byte(`{"type":"item.completed"}`)), "expected no error")
+
+		deliveries, err := repo.GetDeliveries(allId, 10)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, deliveries, 2, "expected webhook without filter to get every event")
+
+		deliveries, err = repo.GetDeliveries(completedId, 10)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, deliveries, 1, "expected only subscribed event")
+		assert.Equal(t, todo.EventItemCompleted, deliveries[0].EventType, "unexpected event type")
+		assert.JSONEq(t, `{"type":"item.completed"}`, string(deliveries[0].Payload), "unexpected payload")
+
+		deliveries, err = repo.GetDeliveries(disabledId, 10)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, deliveries, "expected no deliveries for disabled webhook")
+
+		// секрет не отдается при чтении
+		webhook, err := repo.GetById(userId, allId)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, webhook.Secret, "expected secret to be hidden")
+		assert.Empty(t, webhook.Events, "expected no event filter")
+	})
+}
+
+func TestWebhookPostgres_ClaimDue(t *testing.T) {
+	t.Run("claims deliveries once and disables failing webhook", func(t *testing.T) {
+		db, _, _, authRepo, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		_, err := db.Exec("TRUNCATE TABLE users, webhooks, webhook_deliveries RESTART IDENTITY CASCADE")
+		assert.NoError(t, err, "failed to truncate tables")
+
+		userId := createTestUser(t, authRepo, db)
+		repo := NewWebhookPostgres(db)
+		webhookId, err := repo.Create(userId, todo.Webhook{URL: "http://localhost/hook", Secret: "secret"})
+		assert.NoError(t, err, "expected no error")
+		assert.NoError(t, repo.Enqueue([]int{userId}, todo.EventListCreated, []byte(`{}`)), "expected no error")
+
+		claimed, err := repo.ClaimDue(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, claimed, 1, "expected one delivery")
+		assert.Equal(t, 1, claimed[0].Attempts, "expected attempts to be incremented")
+		assert.Equal(t, "http://localhost/hook", claimed[0].URL, "expected webhook url")
+		assert.Equal(t, "secret", claimed[0].Secret, "expected webhook secret")
+
+		// повторный захват в пределах lease ничего не возвращает
+		claimed, err = repo.ClaimDue(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, claimed, "expected delivery to stay claimed")
+
+		status := 500
+		assert.NoError(t, repo.MarkRetry(1, time.Now().Add(-time.Second), &status, "webhook responded with status 500"), "expected no error")
+		disabled, err := repo.RecordFailure(webhookId, 2)
+		assert.NoError(t, err, "expected no error")
+		assert.False(t, disabled, "expected webhook to stay active after first failure")
+		disabled, err = repo.RecordFailure(webhookId, 2)
+		assert.NoError(t, err, "expected no error")
+		assert.True(t, disabled, "expected webhook to be disabled")
+
+		// доставки отключенного вебхука ждут включения
+		claimed, err = repo.ClaimDue(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Empty(t, claimed, "expected no deliveries for disabled webhook")
+
+		active := true
+		assert.NoError(t, repo.Update(userId, webhookId, todo.UpdateWebhookInput{Active: &active}), "expected no error")
+		webhook, err := repo.GetById(userId, webhookId)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, 0, webhook.FailureCount, "expected failures to be reset")
+		assert.Nil(t, webhook.DisabledAt, "expected disabled_at to be cleared")
+
+		claimed, err = repo.ClaimDue(10, time.Minute)
+		assert.NoError(t, err, "expected no error")
+		assert.Len(t, claimed, 1, "expected delivery to be retried")
+
+		assert.NoError(t, repo.MarkDelivered(claimed[0].Id, 204), "expected no error")
+		deliveries, err := repo.GetDeliveries(webhookId, 10)
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, todo.WebhookDeliveryDelivered, deliveries[0].Status, "expected delivered status")
+		assert.Equal(t, 204, *deliveries[0].ResponseStatus, "expected response status")
+		assert.Nil(t, deliveries[0].LastError, "expected last error to be cleared")
+	})
+}
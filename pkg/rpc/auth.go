@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/rpc/todopb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const authorizationMetadata = "authorization"
+
+// publicMethods не требуют токена.
+var publicMethods = map[string]bool{
+	todopb.TodoService_SignUp_FullMethodName: true,
+	todopb.TodoService_SignIn_FullMethodName: true,
+}
+
+type userIdKey struct{}
+
+// wrappedStream подменяет контекст потока на контекст с id пользователя.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context {
+	return w.ctx
+}
+
+func (s *Server) unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *Server) streamAuth(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &wrappedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticate проверяет токен из метаданных authorization в формате
+// "Bearer <token>", как заголовок Authorization в REST.
+func (s *Server) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationMetadata)
+	if len(values) == 0 || values[0] == "" {
+		return nil, status.Error(codes.Unauthenticated, "empty auth metadata")
+	}
+
+	parts := strings.Split(values[0], " ")
+	if len(parts) != 2 {
+		return nil, status.Error(codes.Unauthenticated, "invalid auth metadata")
+	}
+
+	userId, err := s.services.Authorization.ParseToken(parts[1])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return context.WithValue(ctx, userIdKey{}, userId), nil
+}
+
+func getUserId(ctx context.Context) (int, error) {
+	userId, ok := ctx.Value(userIdKey{}).(int)
+	if !ok {
+		return 0, status.Error(codes.Internal, "user id not found")
+	}
+
+	return userId, nil
+}
+
+func (s *Server) SignUp(ctx context.Context, req *todopb.SignUpRequest) (*todopb.SignUpResponse, error) {
+	if req.GetName() == "" || req.GetUsername() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name, username and password are required")
+	}
+
+	id, err := s.services.Authorization.CreateUser(todo.User{
+		Name:     req.GetName(),
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &todopb.SignUpResponse{Id: int64(id)}, nil
+}
+
+func (s *Server) SignIn(ctx context.Context, req *todopb.SignInRequest) (*todopb.SignInResponse, error) {
+	if req.GetUsername() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+	}
+
+	token, err := s.services.Authorization.GenerateToken(req.GetUsername(), req.GetPassword())
+	if errors.Is(err, todo.ErrNotFound) {
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &todopb.SignInResponse{Token: token}, nil
+}
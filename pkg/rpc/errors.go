@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"errors"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serviceError переводит ошибку сервиса в статус gRPC по ее категории, как
+// errorStatus в REST. Текст ошибок без категории клиенту не показывается.
+func serviceError(err error) error {
+	code := errorCode(err)
+	if code == codes.Internal {
+		logrus.Error(err.Error())
+		return status.Error(code, "internal server error")
+	}
+
+	return status.Error(code, err.Error())
+}
+
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, todo.ErrValidation):
+		return codes.InvalidArgument
+	case errors.Is(err, todo.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, todo.ErrForbidden):
+		return codes.PermissionDenied
+	case errors.Is(err, todo.ErrConflict):
+		return codes.AlreadyExists
+	case errors.Is(err, todo.ErrPreconditionFailed):
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/rpc/todopb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WatchEvents отдает события списков пользователя, пока клиент не отменит
+// вызов или не остановится сервер.
+func (s *Server) WatchEvents(req *todopb.WatchEventsRequest, stream grpc.ServerStreamingServer[todopb.Event]) error {
+	ctx := stream.Context()
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	events, err := s.services.Events.Subscribe(ctx, userId, req.GetLastEventId())
+	if err != nil {
+		return serviceError(err)
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(eventMessage(event)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func eventMessage(event todo.Event) *todopb.Event {
+	return &todopb.Event{
+		Id:      event.Id,
+		Type:    event.Type,
+		ListId:  int64(event.ListId),
+		ItemId:  int64(event.ItemId),
+		ActorId: int64(event.ActorId),
+		At:      timestamppb.New(event.At),
+	}
+}
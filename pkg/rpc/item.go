@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/rpc/todopb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Server) CreateItem(ctx context.Context, req *todopb.CreateItemRequest) (*todopb.CreateItemResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetTitle() == "" {
+		return nil, status.Error(codes.InvalidArgument, "title is required")
+	}
+
+	id, err := s.services.TodoItem.Create(userId, int(req.GetListId()), todo.TodoItem{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		DueAt:       timePtr(req.GetDueAt()),
+		Priority:    int(req.GetPriority()),
+		Tags:        req.GetTags(),
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &todopb.CreateItemResponse{Id: int64(id)}, nil
+}
+
+func (s *Server) ListItems(ctx context.Context, req *todopb.ListItemsRequest) (*todopb.ListItemsResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := todo.ItemFilter{
+		Done:      req.Done,
+		DueBefore: timePtr(req.GetDueBefore()),
+		DueAfter:  timePtr(req.GetDueAfter()),
+		Priority:  int32Ptr(req.Priority),
+		Tag:       req.GetTag(),
+		Query:     req.GetQ(),
+	}
+	items, nextCursor, err := s.services.TodoItem.GetAll(userId, int(req.GetListId()), filter, pageInput(req.GetPage()))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	resp := &todopb.ListItemsResponse{NextCursor: nextCursor}
+	for _, item := range items {
+		resp.Items = append(resp.Items, itemMessage(item))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GetItem(ctx context.Context, req *todopb.GetItemRequest) (*todopb.TodoItem, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.services.TodoItem.GetById(userId, int(req.GetId()))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return itemMessage(item), nil
+}
+
+func (s *Server) UpdateItem(ctx context.Context, req *todopb.UpdateItemRequest) (*todopb.UpdateItemResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.GetTags()) > 0 && req.GetClearTags() {
+		return nil, status.Error(codes.InvalidArgument, "tags cannot be set and cleared at once")
+	}
+
+	var tags *[]string
+	if len(req.GetTags()) > 0 || req.GetClearTags() {
+		// при clear_tags - пустой массив, а не nil
+		value := append([]string{}, req.GetTags()...)
+		tags = &value
+	}
+
+	undoToken, err := s.services.TodoItem.Update(userId, int(req.GetId()), todo.UpdateItemInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Done:        req.Done,
+		DueAt:       timePtr(req.GetDueAt()),
+		ClearDueAt:  req.GetClearDueAt(),
+		Priority:    int32Ptr(req.Priority),
+		Tags:        tags,
+		Version:     intPtr(req.Version),
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &todopb.UpdateItemResponse{UndoToken: undoToken}, nil
+}
+
+func (s *Server) DeleteItem(ctx context.Context, req *todopb.DeleteItemRequest) (*todopb.DeleteItemResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	undoToken, err := s.services.TodoItem.Delete(userId, int(req.GetId()), intPtr(req.Version))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &todopb.DeleteItemResponse{UndoToken: undoToken}, nil
+}
+
+func itemMessage(item todo.TodoItem) *todopb.TodoItem {
+	msg := &todopb.TodoItem{
+		Id:          int64(item.Id),
+		Title:       item.Title,
+		Description: item.Description,
+		Done:        item.Done,
+		Version:     int64(item.Version),
+		Priority:    int32(item.Priority),
+		Tags:        item.Tags,
+	}
+	if item.DueAt != nil {
+		msg.DueAt = timestamppb.New(*item.DueAt)
+	}
+	if item.AssigneeId != nil {
+		assigneeId := int64(*item.AssigneeId)
+		msg.AssigneeId = &assigneeId
+	}
+
+	return msg
+}
+
+// timePtr переводит необязательную метку времени в *time.Time; nil - поле не
+// задано.
+func timePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+
+	t := ts.AsTime()
+	return &t
+}
+
+// int32Ptr - intPtr для необязательных полей int32.
+func int32Ptr(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+
+	i := int(*v)
+	return &i
+}
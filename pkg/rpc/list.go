@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"context"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/rpc/todopb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) CreateList(ctx context.Context, req *todopb.CreateListRequest) (*todopb.CreateListResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetTitle() == "" {
+		return nil, status.Error(codes.InvalidArgument, "title is required")
+	}
+
+	id, err := s.services.TodoList.Create(userId, todo.TodoList{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &todopb.CreateListResponse{Id: int64(id)}, nil
+}
+
+func (s *Server) ListLists(ctx context.Context, req *todopb.ListListsRequest) (*todopb.ListListsResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lists, nextCursor, err := s.services.TodoList.GetAll(userId, pageInput(req.GetPage()))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	resp := &todopb.ListListsResponse{NextCursor: nextCursor}
+	for _, list := range lists {
+		resp.Lists = append(resp.Lists, listMessage(list))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GetList(ctx context.Context, req *todopb.GetListRequest) (*todopb.TodoList, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.services.TodoList.GetById(userId, int(req.GetId()))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return listMessage(list), nil
+}
+
+func (s *Server) UpdateList(ctx context.Context, req *todopb.UpdateListRequest) (*todopb.UpdateListResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	undoToken, err := s.services.TodoList.Update(userId, int(req.GetId()), todo.UpdateListInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Version:     intPtr(req.Version),
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &todopb.UpdateListResponse{UndoToken: undoToken}, nil
+}
+
+func (s *Server) DeleteList(ctx context.Context, req *todopb.DeleteListRequest) (*todopb.DeleteListResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	undoToken, err := s.services.TodoList.Delete(userId, int(req.GetId()), intPtr(req.Version))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &todopb.DeleteListResponse{UndoToken: undoToken}, nil
+}
+
+func listMessage(list todo.TodoList) *todopb.TodoList {
+	return &todopb.TodoList{
+		Id:          int64(list.Id),
+		Title:       list.Title,
+		Description: list.Description,
+		Version:     int64(list.Version),
+	}
+}
+
+func pageInput(page *todopb.Page) todo.PageInput {
+	return todo.PageInput{Limit: int(page.GetLimit()), Cursor: page.GetCursor()}
+}
+
+// intPtr переводит необязательное поле сообщения в *int сервисов.
+func intPtr(v *int64) *int {
+	if v == nil {
+		return nil
+	}
+
+	i := int(*v)
+	return &i
+}
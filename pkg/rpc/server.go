@@ -0,0 +1,31 @@
+// Package rpc отдает TodoService по gRPC поверх тех же сервисов, что и REST.
+package rpc
+
+//go:generate protoc -I ../../proto --go_out=todopb --go_opt=paths=source_relative --go-grpc_out=todopb --go-grpc_opt=paths=source_relative todo/v1/todo.proto
+
+import (
+	"github.com/balamuteon/todo_restapi/pkg/rpc/todopb"
+	"github.com/balamuteon/todo_restapi/pkg/service"
+	"google.golang.org/grpc"
+)
+
+type Server struct {
+	todopb.UnimplementedTodoServiceServer
+	services *service.Service
+}
+
+func NewServer(services *service.Service) *Server {
+	return &Server{services: services}
+}
+
+// InitServer создает gRPC-сервер с проверкой токена и регистрирует в нем
+// TodoService.
+func (s *Server) InitServer() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.unaryAuth),
+		grpc.ChainStreamInterceptor(s.streamAuth),
+	)
+	todopb.RegisterTodoServiceServer(srv, s)
+
+	return srv
+}
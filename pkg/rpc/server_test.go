@@ -0,0 +1,317 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/cache"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/balamuteon/todo_restapi/pkg/rpc/todopb"
+	"github.com/balamuteon/todo_restapi/pkg/service"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeAuth принимает только токен "valid" пользователя 1.
+type fakeAuth struct {
+	service.Authorization
+}
+
+func (f *fakeAuth) ParseToken(token string) (int, error) {
+	if token != "valid" {
+		return 0, errors.New("invalid token")
+	}
+
+	return 1, nil
+}
+
+func (f *fakeAuth) GenerateToken(username, password string) (string, error) {
+	if password != "secret" {
+		return "", todo.NewError(todo.ErrNotFound, "user not found")
+	}
+
+	return "valid", nil
+}
+
+// fakeLists знает только список 1 пользователя 1.
+type fakeLists struct {
+	service.TodoList
+}
+
+func (f *fakeLists) GetById(userId, listId int) (todo.TodoList, error) {
+	if userId != 1 || listId != 1 {
+		return todo.TodoList{}, todo.NewError(todo.ErrNotFound, "list not found")
+	}
+
+	return todo.TodoList{Id: 1, Title: "Work", Version: 3}, nil
+}
+
+type fakeEvents struct {
+	events      []todo.Event
+	lastEventId string
+}
+
+func (f *fakeEvents) Subscribe(ctx context.Context, userId int, lastEventId string) (<-chan todo.Event, error) {
+	f.lastEventId = lastEventId
+	ch := make(chan todo.Event, len(f.events))
+	for _, event := range f.events {
+		ch <- event
+	}
+	close(ch)
+
+	return ch, nil
+}
+
+func newTestClient(t *testing.T, services *service.Service) todopb.TodoServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	srv := NewServer(services).InitServer()
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "failed to create client")
+	t.Cleanup(func() { conn.Close() })
+
+	return todopb.NewTodoServiceClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationMetadata, "Bearer "+token)
+}
+
+func TestServer_Auth(t *testing.T) {
+	client := newTestClient(t, &service.Service{Authorization: &fakeAuth{}, TodoList: &fakeLists{}})
+
+	t.Run("sign in does not require token", func(t *testing.T) {
+		resp, err := client.SignIn(context.Background(), &todopb.SignInRequest{Username: "user", Password: "secret"})
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, "valid", resp.GetToken(), "unexpected token")
+
+		_, err = client.SignIn(context.Background(), &todopb.SignInRequest{Username: "user", Password: "wrong"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), "unexpected code")
+	})
+
+	t.Run("rejects calls without valid token", func(t *testing.T) {
+		_, err := client.GetList(context.Background(), &todopb.GetListRequest{Id: 1})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), "expected missing token to be rejected")
+
+		_, err = client.GetList(withToken("expired"), &todopb.GetListRequest{Id: 1})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), "expected invalid token to be rejected")
+	})
+
+	t.Run("passes user from token and maps errors", func(t *testing.T) {
+		list, err := client.GetList(withToken("valid"), &todopb.GetListRequest{Id: 1})
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, "Work", list.GetTitle(), "unexpected title")
+		assert.Equal(t, int64(3), list.GetVersion(), "unexpected version")
+
+		_, err = client.GetList(withToken("valid"), &todopb.GetListRequest{Id: 2})
+		assert.Equal(t, codes.NotFound, status.Code(err), "unexpected code")
+		assert.Equal(t, "list not found", status.Convert(err).Message(), "unexpected message")
+	})
+}
+
+func TestServer_WatchEvents(t *testing.T) {
+	at := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	events := &fakeEvents{events: []todo.Event{
+		{Id: "1700000000000-1", Type: todo.EventItemUpdated, ListId: 1, ItemId: 2, ActorId: 3, At: at},
+	}}
+	client := newTestClient(t, &service.Service{Authorization: &fakeAuth{}, Events: events})
+
+	_, err := recvAll(client.WatchEvents(context.Background(), &todopb.WatchEventsRequest{}))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "expected stream to require token")
+
+	received, err := recvAll(client.WatchEvents(withToken("valid"), &todopb.WatchEventsRequest{LastEventId: "1700000000000-0"}))
+	assert.NoError(t, err, "expected no error")
+	assert.Equal(t, "1700000000000-0", events.lastEventId, "expected last event id to be passed")
+	assert.Len(t, received, 1, "expected one event")
+	assert.Equal(t, todo.EventItemUpdated, received[0].GetType(), "unexpected type")
+	assert.Equal(t, int64(2), received[0].GetItemId(), "unexpected item id")
+	assert.True(t, at.Equal(received[0].GetAt().AsTime()), "unexpected time")
+}
+
+// fakeListRepo хранит список 1, общий для пользователей 1 и 2.
+type fakeListRepo struct {
+	repository.TodoList
+	updated bool
+}
+
+func (f *fakeListRepo) GetById(userId, listId int) (todo.TodoList, error) {
+	return todo.TodoList{Id: listId, Title: "Work", Version: 3}, nil
+}
+
+func (f *fakeListRepo) Update(userId, listId int, input todo.UpdateListInput) error {
+	f.updated = true
+	return nil
+}
+
+func (f *fakeListRepo) GetUserIds(listId int) ([]int, error) {
+	return []int{1, 2}, nil
+}
+
+type fakeUndoRepo struct {
+	repository.Undo
+}
+
+func (f *fakeUndoRepo) Create(userId int, token string, ops []todo.UndoOperation, expiresAt time.Time) error {
+	return nil
+}
+
+type fakeCache struct {
+	cache.Cache
+	deleted []string
+}
+
+func (f *fakeCache) Delete(ctx context.Context, pattern string) error {
+	f.deleted = append(f.deleted, pattern)
+	return nil
+}
+
+func TestServer_UpdateListInvalidatesCache(t *testing.T) {
+	repo, appCache := &fakeListRepo{}, &fakeCache{}
+	lists := service.NewTodoListService(repo, &fakeUndoRepo{}, service.NewCacheInvalidator(appCache, repo, nil))
+	client := newTestClient(t, &service.Service{Authorization: &fakeAuth{}, TodoList: lists})
+
+	title := "Renamed"
+	_, err := client.UpdateList(withToken("valid"), &todopb.UpdateListRequest{Id: 1, Title: &title})
+	assert.NoError(t, err, "expected no error")
+	assert.True(t, repo.updated, "expected list to be updated")
+	assert.ElementsMatch(t, []string{
+		cache.UserListsKey(1) + "*", cache.UserSmartListsKey(1) + "*",
+		cache.UserListsKey(2) + "*", cache.UserSmartListsKey(2) + "*",
+	}, appCache.deleted, "expected cache of all list members to be invalidated")
+}
+
+// fakeItems запоминает, что пришло в сервис, и отдает одну задачу.
+type fakeItems struct {
+	service.TodoItem
+	created todo.TodoItem
+	filter  todo.ItemFilter
+	update  todo.UpdateItemInput
+}
+
+func (f *fakeItems) Create(userId, listId int, item todo.TodoItem) (int, error) {
+	f.created = item
+	return 1, nil
+}
+
+func (f *fakeItems) GetAll(userId, listId int, filter todo.ItemFilter, page todo.PageInput) ([]todo.TodoItem, string, error) {
+	f.filter = filter
+	return []todo.TodoItem{{Id: 1, Title: "Report", Priority: todo.PriorityHigh, Tags: []string{"work"}}}, "", nil
+}
+
+func (f *fakeItems) Update(userId, itemId int, input todo.UpdateItemInput) (string, error) {
+	f.update = input
+	return "", nil
+}
+
+func TestServer_Items(t *testing.T) {
+	items := &fakeItems{}
+	client := newTestClient(t, &service.Service{Authorization: &fakeAuth{}, TodoItem: items})
+	ctx := withToken("valid")
+
+	t.Run("create passes priority and tags", func(t *testing.T) {
+		_, err := client.CreateItem(ctx, &todopb.CreateItemRequest{ListId: 1, Title: "Report", Priority: 3, Tags: []string{"work"}})
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, todo.PriorityHigh, items.created.Priority, "unexpected priority")
+		assert.Equal(t, []string{"work"}, []string(items.created.Tags), "unexpected tags")
+	})
+
+	t.Run("list passes filters and returns priority and tags", func(t *testing.T) {
+		dueBefore := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+		priority := int32(todo.PriorityHigh)
+		resp, err := client.ListItems(ctx, &todopb.ListItemsRequest{
+			ListId:    1,
+			DueBefore: timestamppb.New(dueBefore),
+			Priority:  &priority,
+			Tag:       "work",
+			Q:         "done:false",
+		})
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, dueBefore, *items.filter.DueBefore, "unexpected due_before")
+		assert.Nil(t, items.filter.DueAfter, "expected due_after to be unset")
+		assert.Equal(t, todo.PriorityHigh, *items.filter.Priority, "unexpected priority")
+		assert.Equal(t, "work", items.filter.Tag, "unexpected tag")
+		assert.Equal(t, "done:false", items.filter.Query, "unexpected query")
+
+		assert.Len(t, resp.GetItems(), 1, "expected one item")
+		assert.Equal(t, int32(todo.PriorityHigh), resp.GetItems()[0].GetPriority(), "unexpected priority")
+		assert.Equal(t, []string{"work"}, resp.GetItems()[0].GetTags(), "unexpected tags")
+	})
+
+	t.Run("update sets and clears tags", func(t *testing.T) {
+		priority := int32(todo.PriorityLow)
+		_, err := client.UpdateItem(ctx, &todopb.UpdateItemRequest{Id: 1, Priority: &priority, Tags: []string{"home"}})
+		assert.NoError(t, err, "expected no error")
+		assert.Equal(t, todo.PriorityLow, *items.update.Priority, "unexpected priority")
+		assert.Equal(t, []string{"home"}, *items.update.Tags, "unexpected tags")
+
+		_, err = client.UpdateItem(ctx, &todopb.UpdateItemRequest{Id: 1, ClearTags: true})
+		assert.NoError(t, err, "expected no error")
+		assert.Nil(t, items.update.Priority, "expected priority to be unchanged")
+		assert.Equal(t, []string{}, *items.update.Tags, "expected tags to be cleared")
+
+		title := "Report"
+		_, err = client.UpdateItem(ctx, &todopb.UpdateItemRequest{Id: 1, Title: &title})
+		assert.NoError(t, err, "expected no error")
+		assert.Nil(t, items.update.Tags, "expected tags to be unchanged")
+
+		_, err = client.UpdateItem(ctx, &todopb.UpdateItemRequest{Id: 1, Tags: []string{"home"}, ClearTags: true})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "expected conflicting tags to be rejected")
+	})
+}
+
+func recvAll(stream grpc.ServerStreamingClient[todopb.Event], err error) ([]*todopb.Event, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*todopb.Event
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"validation", todo.NewError(todo.ErrValidation, "bad"), codes.InvalidArgument},
+		{"not found", todo.NewError(todo.ErrNotFound, "missing"), codes.NotFound},
+		{"forbidden", todo.NewError(todo.ErrForbidden, "denied"), codes.PermissionDenied},
+		{"conflict", todo.NewError(todo.ErrConflict, "exists"), codes.AlreadyExists},
+		{"precondition", todo.NewError(todo.ErrPreconditionFailed, "modified"), codes.FailedPrecondition},
+		{"unknown", errors.New("pq: connection refused"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errorCode(tt.err), "unexpected code")
+		})
+	}
+
+	assert.Equal(t, "internal server error", status.Convert(serviceError(errors.New("pq: connection refused"))).Message(),
+		"expected internal error text to be hidden")
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/balamuteon/todo_restapi/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+type AttachmentLimits struct {
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+type AttachmentService struct {
+	repo     repository.Attachment
+	itemRepo repository.TodoItem
+	store    storage.BlobStore
+	limits   AttachmentLimits
+}
+
+func NewAttachmentService(repo repository.Attachment, itemRepo repository.TodoItem, store storage.BlobStore, limits AttachmentLimits) *AttachmentService {
+	return &AttachmentService{repo: repo, itemRepo: itemRepo, store: store, limits: limits}
+}
+
+// MaxSize возвращает наибольший допустимый размер вложения в байтах.
+func (s *AttachmentService) MaxSize() int64 {
+	return s.limits.MaxSize
+}
+
+func (s *AttachmentService) Upload(ctx context.Context, userId, itemId int, filename string, size int64, content io.Reader) (todo.Attachment, error) {
+	if size > s.limits.MaxSize {
+		return todo.Attachment{}, todo.NewError(todo.ErrValidation, "attachment exceeds max size of %d bytes", s.limits.MaxSize)
+	}
+
+	_, err := s.itemRepo.GetById(userId, itemId)
+	if err != nil {
+		// item doesn't exist or user isn't a member of its list
+		return todo.Attachment{}, err
+	}
+
+	// тип определяем по содержимому, а не по заголовку клиента
+	reader := bufio.NewReaderSize(content, 512)
+	head, err := reader.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return todo.Attachment{}, err
+	}
+	contentType := http.DetectContentType(head)
+	if !s.allowed(contentType) {
+		return todo.Attachment{}, todo.NewError(todo.ErrValidation, "attachment type %s is not allowed", contentType)
+	}
+
+	key, err := newStorageKey()
+	if err != nil {
+		return todo.Attachment{}, err
+	}
+
+	hash := sha256.New()
+	if err := s.store.Put(ctx, key, io.TeeReader(reader, hash), size, contentType); err != nil {
+		return todo.Attachment{}, err
+	}
+
+	attachment := todo.Attachment{
+		ItemId:      itemId,
+		UploaderId:  userId,
+		Filename:    filepath.Base(filename),
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+	}
+
+	attachment.Id, err = s.repo.Create(attachment)
+	if err != nil {
+		s.deleteBlob(ctx, key)
+		return todo.Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+func (s *AttachmentService) GetAll(userId, itemId int) ([]todo.Attachment, error) {
+	_, err := s.itemRepo.GetById(userId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAll(userId, itemId)
+}
+
+func (s *AttachmentService) Open(ctx context.Context, userId, itemId, attachmentId int) (todo.Attachment, storage.Object, error) {
+	_, err := s.itemRepo.GetById(userId, itemId)
+	if err != nil {
+		return todo.Attachment{}, nil, err
+	}
+
+	attachment, err := s.repo.GetById(userId, itemId, attachmentId)
+	if err != nil {
+		return attachment, nil, err
+	}
+
+	obj, err := s.store.Open(ctx, attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return attachment, nil, todo.NewError(todo.ErrNotFound, "attachment content not found")
+	}
+	if err != nil {
+		return attachment, nil, err
+	}
+
+	return attachment, obj, nil
+}
+
+func (s *AttachmentService) Delete(ctx context.Context, userId, itemId, attachmentId int) error {
+	_, err := s.itemRepo.GetById(userId, itemId)
+	if err != nil {
+		return err
+	}
+
+	key, err := s.repo.Delete(userId, itemId, attachmentId)
+	if err != nil {
+		return err
+	}
+
+	s.deleteBlob(ctx, key)
+
+	return nil
+}
+
+func (s *AttachmentService) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range s.limits.AllowedTypes {
+		if allowed == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deleteBlob не возвращает ошибку: запись в БД уже удалена, а осиротевший блоб
+// не влияет на пользователя.
+func (s *AttachmentService) deleteBlob(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil {
+		logrus.Errorf("failed to delete blob %s: %s", key, err.Error())
+	}
+}
+
+func newStorageKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
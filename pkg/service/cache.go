@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+
+	"github.com/balamuteon/todo_restapi/pkg/cache"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// CacheInvalidator сбрасывает кэш выборок после изменений. Кэш читает
+// HTTP-слой, но сбрасывается он здесь, чтобы изменения через любой транспорт
+// были видны сразу. Ошибки только логируются: изменение уже зафиксировано, а
+// запись в кэше истечет сама.
+type CacheInvalidator struct {
+	cache    cache.Cache
+	listRepo repository.TodoList
+	itemRepo repository.TodoItem
+}
+
+func NewCacheInvalidator(cache cache.Cache, listRepo repository.TodoList, itemRepo repository.TodoItem) *CacheInvalidator {
+	return &CacheInvalidator{cache: cache, listRepo: listRepo, itemRepo: itemRepo}
+}
+
+// Lists сбрасывает кэш списков пользователей.
+func (i *CacheInvalidator) Lists(userIds ...int) {
+	for _, userId := range userIds {
+		i.delete(cache.UserListsKey(userId) + "*")
+	}
+}
+
+// SmartLists сбрасывает умные списки пользователей.
+func (i *CacheInvalidator) SmartLists(userIds ...int) {
+	for _, userId := range userIds {
+		i.delete(cache.UserSmartListsKey(userId) + "*")
+	}
+}
+
+// ListChanged сбрасывает у всех участников списка и сам список, и умные
+// списки: в них выводится название списка, и с ним могли исчезнуть или
+// вернуться его задачи.
+func (i *CacheInvalidator) ListChanged(listId int) {
+	userIds := i.members(listId)
+	i.Lists(userIds...)
+	i.SmartLists(userIds...)
+}
+
+// ItemsChanged сбрасывает умные списки всех участников списков: изменение
+// задачи попадает в выборки каждого из них.
+func (i *CacheInvalidator) ItemsChanged(listIds ...int) {
+	i.SmartLists(i.members(listIds...)...)
+}
+
+// ItemChanged - ItemsChanged для списков задач, в том числе задач в корзине.
+func (i *CacheInvalidator) ItemChanged(itemIds ...int) {
+	listIds := make([]int, 0, len(itemIds))
+	for _, itemId := range itemIds {
+		listId, err := i.itemRepo.GetListId(itemId)
+		if err != nil {
+			logrus.Errorf("failed to get item list for cache invalidation: %v", err)
+			continue
+		}
+		listIds = append(listIds, listId)
+	}
+
+	i.ItemsChanged(listIds...)
+}
+
+// members возвращает участников списков, каждого пользователя - один раз.
+func (i *CacheInvalidator) members(listIds ...int) []int {
+	seen := make(map[int]bool)
+	userIds := make([]int, 0)
+	for _, listId := range listIds {
+		members, err := i.listRepo.GetUserIds(listId)
+		if err != nil {
+			logrus.Errorf("failed to get list members for cache invalidation: %v", err)
+			continue
+		}
+
+		for _, userId := range members {
+			if !seen[userId] {
+				seen[userId] = true
+				userIds = append(userIds, userId)
+			}
+		}
+	}
+
+	return userIds
+}
+
+func (i *CacheInvalidator) delete(pattern string) {
+	if err := i.cache.Delete(context.Background(), pattern); err != nil {
+		logrus.Errorf("failed to invalidate cache: %v", err)
+	}
+}
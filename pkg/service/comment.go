@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+)
+
+type CommentService struct {
+	repo     repository.Comment
+	itemRepo repository.TodoItem
+}
+
+func NewCommentService(repo repository.Comment, itemRepo repository.TodoItem) *CommentService {
+	return &CommentService{repo: repo, itemRepo: itemRepo}
+}
+
+func (s *CommentService) Create(userId, itemId int, comment todo.Comment) (int, error) {
+	if strings.TrimSpace(comment.Body) == "" {
+		return 0, todo.NewError(todo.ErrValidation, "comment body is empty")
+	}
+
+	_, err := s.itemRepo.GetById(userId, itemId)
+	if err != nil {
+		// item doesn't exist or user isn't a member of its list
+		return 0, err
+	}
+
+	return s.repo.Create(userId, itemId, comment)
+}
+
+func (s *CommentService) GetAll(userId, itemId int) ([]todo.Comment, error) {
+	_, err := s.itemRepo.GetById(userId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAll(userId, itemId)
+}
+
+func (s *CommentService) Update(userId, itemId, commentId int, input todo.UpdateCommentInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	_, err := s.itemRepo.GetById(userId, itemId)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Update(userId, itemId, commentId, input)
+}
+
+func (s *CommentService) Delete(userId, itemId, commentId int) error {
+	_, err := s.itemRepo.GetById(userId, itemId)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Delete(userId, itemId, commentId)
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/events"
+)
+
+type EventsService struct {
+	broker events.Broker
+}
+
+func NewEventsService(broker events.Broker) *EventsService {
+	return &EventsService{broker: broker}
+}
+
+// Subscribe отдает события списков, в которых состоит пользователь, до отмены
+// ctx. lastEventId - id последнего полученного события, с него поток
+// продолжается, пока событие есть в буфере брокера.
+func (s *EventsService) Subscribe(ctx context.Context, userId int, lastEventId string) (<-chan todo.Event, error) {
+	ch, err := s.broker.Subscribe(ctx, userId, lastEventId)
+	if errors.Is(err, events.ErrInvalidEventId) {
+		return nil, todo.NewError(todo.ErrValidation, "invalid Last-Event-ID")
+	}
+
+	return ch, err
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 100
+)
+
+type HistoryService struct {
+	repo repository.History
+}
+
+func NewHistoryService(repo repository.History) *HistoryService {
+	return &HistoryService{repo: repo}
+}
+
+func (s *HistoryService) GetForList(userId, listId, limit, offset int) ([]todo.HistoryEntry, error) {
+	limit, offset = normalizePage(limit, offset)
+	return s.repo.GetForList(userId, listId, limit, offset)
+}
+
+func (s *HistoryService) GetForItem(userId, itemId, limit, offset int) ([]todo.HistoryEntry, error) {
+	limit, offset = normalizePage(limit, offset)
+	return s.repo.GetForItem(userId, itemId, limit, offset)
+}
+
+func normalizePage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/patch"
+)
+
+// patchChanges применяет патч к JSON-представлению ресурса и возвращает поля,
+// значение которых изменилось. Удаленное поле считается явным null.
+func patchChanges(resource interface{}, contentType string, body []byte) (map[string]interface{}, error) {
+	original, err := patch.NewDocument(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := patch.Apply(contentType, original, body)
+	if err != nil {
+		return nil, patchError(err)
+	}
+
+	changes := make(map[string]interface{})
+	for key, value := range patched {
+		if !reflect.DeepEqual(original[key], value) {
+			changes[key] = value
+		}
+	}
+	for key, value := range original {
+		if _, ok := patched[key]; !ok && value != nil {
+			changes[key] = nil
+		}
+	}
+
+	return changes, nil
+}
+
+// checkVersion сверяет текущую версию записи с ожидаемой; expected nil - без
+// проверки.
+func checkVersion(entity string, current int, expected *int) error {
+	if expected != nil && *expected != current {
+		return todo.NewError(todo.ErrPreconditionFailed, "%s has been modified", entity)
+	}
+
+	return nil
+}
+
+func patchError(err error) error {
+	var patchErr *patch.Error
+	switch {
+	case errors.Is(err, patch.ErrTestFailed):
+		return todo.NewError(todo.ErrConflict, "%s", err.Error())
+	case errors.As(err, &patchErr), errors.Is(err, patch.ErrUnsupportedType):
+		return todo.NewError(todo.ErrValidation, "%s", err.Error())
+	default:
+		return err
+	}
+}
+
+// listPatchInput переводит изменения списка в UpdateListInput. null в
+// description очищает описание.
+func listPatchInput(changes map[string]interface{}) (todo.UpdateListInput, error) {
+	var input todo.UpdateListInput
+	for _, key := range sortedKeys(changes) {
+		value := changes[key]
+		switch key {
+		case "title":
+			input.Title = new(string)
+			if err := decodePatchValue(key, value, input.Title); err != nil {
+				return input, err
+			}
+		case "description":
+			input.Description = new(string)
+			if value == nil {
+				continue
+			}
+			if err := decodePatchValue(key, value, input.Description); err != nil {
+				return input, err
+			}
+		default:
+			return input, readOnlyFieldError(key)
+		}
+	}
+
+	return input, nil
+}
+
+// itemPatchInput переводит изменения задачи в UpdateItemInput. null в
+// description очищает описание, в due_at - снимает срок, в tags - снимает теги.
+func itemPatchInput(changes map[string]interface{}) (todo.UpdateItemInput, error) {
+	var input todo.UpdateItemInput
+	for _, key := range sortedKeys(changes) {
+		value := changes[key]
+		switch key {
+		case "title":
+			input.Title = new(string)
+			if err := decodePatchValue(key, value, input.Title); err != nil {
+				return input, err
+			}
+		case "description":
+			input.Description = new(string)
+			if value == nil {
+				continue
+			}
+			if err := decodePatchValue(key, value, input.Description); err != nil {
+				return input, err
+			}
+		case "done":
+			input.Done = new(bool)
+			if err := decodePatchValue(key, value, input.Done); err != nil {
+				return input, err
+			}
+		case "due_at":
+			if value == nil {
+				input.ClearDueAt = true
+				continue
+			}
+			input.DueAt = new(time.Time)
+			if err := decodePatchValue(key, value, input.DueAt); err != nil {
+				return input, err
+			}
+		case "priority":
+			input.Priority = new(int)
+			if err := decodePatchValue(key, value, input.Priority); err != nil {
+				return input, err
+			}
+		case "tags":
+			input.Tags = new([]string)
+			if value == nil {
+				continue
+			}
+			if err := decodePatchValue(key, value, input.Tags); err != nil {
+				return input, err
+			}
+		default:
+			return input, readOnlyFieldError(key)
+		}
+	}
+
+	return input, nil
+}
+
+func decodePatchValue(key string, value interface{}, target interface{}) error {
+	if value == nil {
+		return todo.NewError(todo.ErrValidation, "%s cannot be null", key)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		return todo.NewError(todo.ErrValidation, "%s has invalid value", key)
+	}
+
+	return nil
+}
+
+func readOnlyFieldError(key string) error {
+	return todo.NewError(todo.ErrValidation, "%s cannot be patched", key)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+)
+
+type ReminderService struct {
+	repo     repository.Reminder
+	itemRepo repository.TodoItem
+}
+
+func NewReminderService(repo repository.Reminder, itemRepo repository.TodoItem) *ReminderService {
+	return &ReminderService{repo: repo, itemRepo: itemRepo}
+}
+
+func (s *ReminderService) Create(userId, itemId int, reminder todo.Reminder) (int, error) {
+	if err := reminder.Validate(); err != nil {
+		return 0, err
+	}
+
+	item, err := s.itemRepo.GetById(userId, itemId)
+	if err != nil {
+		// item doesn't exist or user doesn't have access to it
+		return 0, err
+	}
+
+	if reminder.OffsetSeconds != nil && item.DueAt == nil {
+		return 0, todo.NewError(todo.ErrValidation, "item has no due date to offset from")
+	}
+
+	return s.repo.Create(userId, itemId, reminder)
+}
+
+func (s *ReminderService) GetAll(userId, itemId int) ([]todo.Reminder, error) {
+	_, err := s.itemRepo.GetById(userId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAll(userId, itemId)
+}
+
+func (s *ReminderService) Delete(userId, reminderId int) error {
+	return s.repo.Delete(userId, reminderId)
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/query"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+)
+
+// Сохраненные фильтры выводятся вместе со списками, поэтому их изменения
+// сбрасывают кэш списков, а изменения запроса - еще и умные списки.
+type SavedFilterService struct {
+	repo  repository.SavedFilter
+	cache *CacheInvalidator
+}
+
+func NewSavedFilterService(repo repository.SavedFilter, cache *CacheInvalidator) *SavedFilterService {
+	return &SavedFilterService{repo: repo, cache: cache}
+}
+
+func (s *SavedFilterService) Create(userId int, filter todo.SavedFilter) (int, error) {
+	if strings.TrimSpace(filter.Name) == "" {
+		return 0, todo.NewError(todo.ErrValidation, "filter name is empty")
+	}
+
+	// сохраняем только разбираемые запросы, чтобы умный список не ломался при чтении
+	if _, err := query.Parse(filter.Query, time.Now().UTC()); err != nil {
+		return 0, err
+	}
+
+	id, err := s.repo.Create(userId, filter)
+	if err != nil {
+		return 0, err
+	}
+	s.cache.Lists(userId)
+
+	return id, nil
+}
+
+func (s *SavedFilterService) GetAll(userId int) ([]todo.SavedFilter, error) {
+	return s.repo.GetAll(userId)
+}
+
+func (s *SavedFilterService) GetById(userId, filterId int) (todo.SavedFilter, error) {
+	return s.repo.GetById(userId, filterId)
+}
+
+func (s *SavedFilterService) Update(userId, filterId int, input todo.UpdateSavedFilterInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	if input.Query != nil {
+		if _, err := query.Parse(*input.Query, time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.Update(userId, filterId, input); err != nil {
+		return err
+	}
+	s.cache.Lists(userId)
+	s.cache.SmartLists(userId)
+
+	return nil
+}
+
+func (s *SavedFilterService) Delete(userId, filterId int) error {
+	if err := s.repo.Delete(userId, filterId); err != nil {
+		return err
+	}
+	s.cache.Lists(userId)
+	s.cache.SmartLists(userId)
+
+	return nil
+}
+
+type SmartListService struct {
+	filterRepo repository.SavedFilter
+	itemRepo   repository.TodoItem
+}
+
+func NewSmartListService(filterRepo repository.SavedFilter, itemRepo repository.TodoItem) *SmartListService {
+	return &SmartListService{filterRepo: filterRepo, itemRepo: itemRepo}
+}
+
+// GetAll возвращает встроенные умные списки и сохраненные фильтры пользователя.
+func (s *SmartListService) GetAll(userId int) ([]todo.SmartList, error) {
+	filters, err := s.filterRepo.GetAll(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([]todo.SmartList, 0, len(todo.BuiltInSmartLists)+len(filters))
+	lists = append(lists, todo.BuiltInSmartLists...)
+	for _, filter := range filters {
+		lists = append(lists, filter.SmartList())
+	}
+
+	return lists, nil
+}
+
+// GetItems вычисляет умный список по всем доступным пользователю задачам.
+func (s *SmartListService) GetItems(userId int, smartListId string, page todo.PageInput) ([]todo.ItemWithList, string, error) {
+	input, err := s.query(userId, smartListId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	expr, err := query.Parse(input, time.Now().UTC())
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := page.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	return s.itemRepo.GetAllForUser(userId, todo.ItemFilter{}, expr, page)
+}
+
+func (s *SmartListService) query(userId int, smartListId string) (string, error) {
+	for _, list := range todo.BuiltInSmartLists {
+		if list.Id == smartListId {
+			return list.Query, nil
+		}
+	}
+
+	filterId, err := strconv.Atoi(smartListId)
+	if err != nil {
+		return "", todo.NewError(todo.ErrNotFound, "smart list not found")
+	}
+
+	filter, err := s.filterRepo.GetById(userId, filterId)
+	if err != nil {
+		return "", err
+	}
+
+	return filter.Query, nil
+}
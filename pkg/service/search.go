@@ -0,0 +1,22 @@
+package service
+
+import (
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+)
+
+type SearchService struct {
+	repo repository.Search
+}
+
+func NewSearchService(repo repository.Search) *SearchService {
+	return &SearchService{repo: repo}
+}
+
+func (s *SearchService) Search(userId int, input todo.SearchInput) ([]todo.SearchHit, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Search(userId, input)
+}
@@ -1,8 +1,14 @@
 package service
 
 import (
+	"context"
+	"io"
+
 	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/cache"
+	"github.com/balamuteon/todo_restapi/pkg/events"
 	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/balamuteon/todo_restapi/pkg/storage"
 )
 
 type Authorization interface {
@@ -13,30 +19,127 @@ type Authorization interface {
 
 type TodoList interface {
 	Create(userId int, list todo.TodoList) (int, error)
-	GetAll(userId int) ([]todo.TodoList, error)
+	GetAll(userId int, page todo.PageInput) ([]todo.TodoList, string, error)
 	GetById(userId, listId int) (todo.TodoList, error)
-	Delete(userId, listId int) error
-	Update(userId, listId int, input todo.UpdateListInput) error
+	Delete(userId, listId int, version *int) (string, error)
+	Update(userId, listId int, input todo.UpdateListInput) (string, error)
+	Patch(userId, listId int, contentType string, body []byte, version *int) (string, error)
+	GetUserIds(listId int) ([]int, error)
 }
 
 type TodoItem interface {
 	Create(userId, listId int, item todo.TodoItem) (int, error)
-	GetAll(userId, listId int) ([]todo.TodoItem, error)
+	GetAll(userId, listId int, filter todo.ItemFilter, page todo.PageInput) ([]todo.TodoItem, string, error)
 	GetById(userId, itemId int) (todo.TodoItem, error)
-	Delete(userId, itemId int) error
-	Update(userId, itemId int, input todo.UpdateItemInput) error
+	Delete(userId, itemId int, version *int) (string, error)
+	Update(userId, itemId int, input todo.UpdateItemInput) (string, error)
+	Patch(userId, itemId int, contentType string, body []byte, version *int) (string, error)
+	Assign(userId, itemId int, assigneeId *int, version *int) error
+	GetAssigned(userId int, page todo.PageInput) ([]todo.ItemWithList, string, error)
+	GetAllForUser(userId int, filter todo.ItemFilter, queryInput string, page todo.PageInput) ([]todo.ItemWithList, string, error)
+	GetListId(itemId int) (int, error)
+	Batch(userId int, input todo.ItemBatchInput) ([]todo.ItemOperationResult, string, error)
+}
+
+type Reminder interface {
+	Create(userId, itemId int, reminder todo.Reminder) (int, error)
+	GetAll(userId, itemId int) ([]todo.Reminder, error)
+	Delete(userId, reminderId int) error
+}
+
+type Comment interface {
+	Create(userId, itemId int, comment todo.Comment) (int, error)
+	GetAll(userId, itemId int) ([]todo.Comment, error)
+	Update(userId, itemId, commentId int, input todo.UpdateCommentInput) error
+	Delete(userId, itemId, commentId int) error
+}
+
+type Attachment interface {
+	Upload(ctx context.Context, userId, itemId int, filename string, size int64, content io.Reader) (todo.Attachment, error)
+	GetAll(userId, itemId int) ([]todo.Attachment, error)
+	Open(ctx context.Context, userId, itemId, attachmentId int) (todo.Attachment, storage.Object, error)
+	Delete(ctx context.Context, userId, itemId, attachmentId int) error
+	MaxSize() int64
+}
+
+type Trash interface {
+	GetAll(userId int) (todo.Trash, error)
+	Restore(userId int, entityType string, id int) error
+}
+
+type History interface {
+	GetForList(userId, listId, limit, offset int) ([]todo.HistoryEntry, error)
+	GetForItem(userId, itemId, limit, offset int) ([]todo.HistoryEntry, error)
+}
+
+type Undo interface {
+	Undo(userId int, token string) error
+}
+
+type Search interface {
+	Search(userId int, input todo.SearchInput) ([]todo.SearchHit, error)
+}
+
+type SavedFilter interface {
+	Create(userId int, filter todo.SavedFilter) (int, error)
+	GetAll(userId int) ([]todo.SavedFilter, error)
+	GetById(userId, filterId int) (todo.SavedFilter, error)
+	Update(userId, filterId int, input todo.UpdateSavedFilterInput) error
+	Delete(userId, filterId int) error
+}
+
+type SmartList interface {
+	GetAll(userId int) ([]todo.SmartList, error)
+	GetItems(userId int, smartListId string, page todo.PageInput) ([]todo.ItemWithList, string, error)
+}
+
+type Events interface {
+	Subscribe(ctx context.Context, userId int, lastEventId string) (<-chan todo.Event, error)
+}
+
+type Webhook interface {
+	Create(userId int, webhook todo.Webhook) (int, string, error)
+	GetAll(userId int) ([]todo.Webhook, error)
+	GetById(userId, webhookId int) (todo.Webhook, error)
+	Update(userId, webhookId int, input todo.UpdateWebhookInput) error
+	Delete(userId, webhookId int) error
+	GetDeliveries(userId, webhookId, limit int) ([]todo.WebhookDelivery, error)
 }
 
 type Service struct {
 	Authorization
 	TodoList
 	TodoItem
+	Reminder
+	Comment
+	Attachment
+	Trash
+	History
+	Undo
+	Search
+	SavedFilter
+	SmartList
+	Events
+	Webhook
 }
 
-func NewService(repos *repository.Repository) *Service {
+func NewService(repos *repository.Repository, cacheClient cache.Cache, store storage.BlobStore, attachmentLimits AttachmentLimits, broker events.Broker) *Service {
+	invalidator := NewCacheInvalidator(cacheClient, repos.TodoList, repos.TodoItem)
+
 	return &Service{
 		Authorization: NewAuthService(repos.Authorization),
-		TodoList:      NewTodoListService(repos.TodoList),
-		TodoItem:      NewTodoItemService(repos.TodoItem, repos.TodoList),
+		TodoList:      NewTodoListService(repos.TodoList, repos.Undo, invalidator),
+		TodoItem:      NewTodoItemService(repos.TodoItem, repos.TodoList, repos.Undo, invalidator),
+		Reminder:      NewReminderService(repos.Reminder, repos.TodoItem),
+		Comment:       NewCommentService(repos.Comment, repos.TodoItem),
+		Attachment:    NewAttachmentService(repos.Attachment, repos.TodoItem, store, attachmentLimits),
+		Trash:         NewTrashService(repos.Trash, invalidator),
+		History:       NewHistoryService(repos.History),
+		Undo:          NewUndoService(repos.Undo, invalidator),
+		Search:        NewSearchService(repos.Search),
+		SavedFilter:   NewSavedFilterService(repos.SavedFilter, invalidator),
+		SmartList:     NewSmartListService(repos.SavedFilter, repos.TodoItem),
+		Events:        NewEventsService(broker),
+		Webhook:       NewWebhookService(repos.Webhook),
 	}
 }
@@ -1,41 +1,342 @@
 package service
 
 import (
+	"errors"
+	"time"
+
 	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/query"
 	"github.com/balamuteon/todo_restapi/pkg/repository"
 )
 
 type TodoItemService struct {
 	repo     repository.TodoItem
 	listRepo repository.TodoList
+	undoRepo repository.Undo
+	cache    *CacheInvalidator
 }
 
-func NewTodoItemService(repo repository.TodoItem, listRepo repository.TodoList) *TodoItemService {
-	return &TodoItemService{repo: repo, listRepo: listRepo}
+func NewTodoItemService(repo repository.TodoItem, listRepo repository.TodoList, undoRepo repository.Undo, cache *CacheInvalidator) *TodoItemService {
+	return &TodoItemService{repo: repo, listRepo: listRepo, undoRepo: undoRepo, cache: cache}
 }
 
 func (s *TodoItemService) Create(userId, listId int, item todo.TodoItem) (int, error) {
+	if err := item.Validate(); err != nil {
+		return 0, err
+	}
+
 	_, err := s.listRepo.GetById(userId, listId)
 	if err != nil {
 		// list doesn't exist or user doesn't own list
 		return 0, err
 	}
 
-	return s.repo.Create(listId, item)
+	id, err := s.repo.Create(userId, listId, item)
+	if err != nil {
+		return 0, err
+	}
+	s.cache.ItemsChanged(listId)
+
+	return id, nil
 }
 
-func (s *TodoItemService) GetAll(userId, listId int) ([]todo.TodoItem, error) {
-	return s.repo.GetAll(userId, listId)
+func (s *TodoItemService) GetAll(userId, listId int, filter todo.ItemFilter, page todo.PageInput) ([]todo.TodoItem, string, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if err := page.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if _, err := s.listRepo.GetById(userId, listId); err != nil {
+		return nil, "", err
+	}
+
+	return s.repo.GetAll(userId, listId, filter, page)
 }
 
 func (s *TodoItemService) GetById(userId, itemId int) (todo.TodoItem, error) {
 	return s.repo.GetById(userId, itemId)
 }
 
-func (s *TodoItemService) Delete(userId, itemId int) error {
-	return s.repo.Delete(userId, itemId)
+// Delete переносит запись в корзину. version - ожидаемая версия из If-Match,
+// nil - без проверки.
+func (s *TodoItemService) Delete(userId, itemId int, version *int) (string, error) {
+	item, err := s.repo.GetById(userId, itemId)
+	if err != nil {
+		return "", err
+	}
+
+	listId, err := s.repo.GetListId(itemId)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.Delete(userId, itemId, version); err != nil {
+		return "", err
+	}
+	s.cache.ItemsChanged(listId)
+
+	return registerUndo(s.undoRepo, userId, todo.UndoOperation{
+		Entity:  todo.UndoEntityItem,
+		Restore: true,
+		Item:    &item,
+		ListId:  listId,
+		Version: undoVersion(item.Version, true),
+	}), nil
+}
+
+func (s *TodoItemService) Update(userId, itemId int, input todo.UpdateItemInput) (string, error) {
+	if err := input.Validate(); err != nil {
+		return "", err
+	}
+
+	item, err := s.repo.GetById(userId, itemId)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.Update(userId, itemId, input); err != nil {
+		return "", err
+	}
+	s.cache.ItemChanged(itemId)
+
+	return registerUndo(s.undoRepo, userId, todo.UndoOperation{
+		Entity:  todo.UndoEntityItem,
+		Item:    &item,
+		Version: undoVersion(item.Version, false),
+	}), nil
+}
+
+// Patch применяет к задаче JSON Merge Patch или JSON Patch. Патч, который ничего
+// не меняет, не записывается и не создает токен отмены. version - ожидаемая
+// версия из If-Match; без нее запись проверяется по прочитанной версии.
+func (s *TodoItemService) Patch(userId, itemId int, contentType string, body []byte, version *int) (string, error) {
+	item, err := s.repo.GetById(userId, itemId)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkVersion("item", item.Version, version); err != nil {
+		return "", err
+	}
+
+	changes, err := patchChanges(item, contentType, body)
+	if err != nil || len(changes) == 0 {
+		return "", err
+	}
+
+	input, err := itemPatchInput(changes)
+	if err != nil {
+		return "", err
+	}
+	// патч вычислен по прочитанной версии, поэтому и без If-Match запись
+	// не должна затереть изменение, сделанное после чтения
+	if version == nil {
+		version = &item.Version
+	}
+	input.Version = version
+
+	return s.Update(userId, itemId, input)
+}
+
+// Assign назначает исполнителя. version - ожидаемая версия из If-Match, nil -
+// без проверки.
+func (s *TodoItemService) Assign(userId, itemId int, assigneeId *int, version *int) error {
+	item, err := s.repo.GetById(userId, itemId)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVersion("item", item.Version, version); err != nil {
+		return err
+	}
+
+	// исполнителем может быть только участник списка задачи
+	if assigneeId != nil {
+		if _, err := s.repo.GetById(*assigneeId, itemId); err != nil {
+			return todo.NewError(todo.ErrValidation, "assignee is not a member of the item's list")
+		}
+	}
+
+	if err := s.repo.Assign(userId, itemId, assigneeId, version); err != nil {
+		return err
+	}
+	s.cache.ItemChanged(itemId)
+
+	return nil
 }
 
-func (s *TodoItemService) Update(userId, itemId int, input todo.UpdateItemInput) error {
-	return s.repo.Update(userId, itemId, input)
+func (s *TodoItemService) GetAssigned(userId int, page todo.PageInput) ([]todo.ItemWithList, string, error) {
+	if err := page.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	return s.repo.GetAssigned(userId, page)
+}
+
+// GetAllForUser возвращает задачи из всех списков пользователя. queryInput -
+// необязательное выражение языка фильтров, ошибка его разбора - *query.Error.
+// Относительные даты считаются в UTC.
+func (s *TodoItemService) GetAllForUser(userId int, filter todo.ItemFilter, queryInput string, page todo.PageInput) ([]todo.ItemWithList, string, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if err := page.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	var expr query.Node
+	if queryInput != "" {
+		var err error
+		if expr, err = query.Parse(queryInput, time.Now().UTC()); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return s.repo.GetAllForUser(userId, filter, expr, page)
+}
+
+// GetListId возвращает список задачи без проверки доступа, в том числе для
+// задачи в корзине.
+func (s *TodoItemService) GetListId(itemId int) (int, error) {
+	return s.repo.GetListId(itemId)
+}
+
+// batchOperation - операция пакета, прошедшая проверку, со снимком задачи до
+// изменения.
+type batchOperation struct {
+	index  int
+	op     todo.ItemOperation
+	item   todo.TodoItem
+	listId int // список задачи до операции
+}
+
+// Batch выполняет пакет операций над задачами в одной транзакции. Доступ к
+// задачам и спискам проверяется заранее; в атомарном режиме первая ошибка
+// возвращается как *todo.BatchOperationError, и пакет ничего не меняет.
+// Возвращает результаты по операциям и один токен отмены для всех изменений и
+// удалений; переносы и создание им не отменяются.
+func (s *TodoItemService) Batch(userId int, input todo.ItemBatchInput) ([]todo.ItemOperationResult, string, error) {
+	if err := input.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	results := make([]todo.ItemOperationResult, len(input.Operations))
+	prepared := make([]batchOperation, 0, len(input.Operations))
+	for i, op := range input.Operations {
+		operation, err := s.prepareBatchOperation(userId, op)
+		if err != nil {
+			if input.Atomic() {
+				return nil, "", &todo.BatchOperationError{Index: i, Err: err}
+			}
+			results[i] = todo.ItemOperationResult{Id: op.Id, Err: err}
+			continue
+		}
+		operation.index = i
+		prepared = append(prepared, operation)
+	}
+
+	if len(prepared) == 0 {
+		return results, "", nil
+	}
+
+	ops := make([]todo.ItemOperation, len(prepared))
+	for i, operation := range prepared {
+		ops[i] = operation.op
+	}
+
+	batchResults, err := s.repo.Batch(userId, ops, input.Atomic())
+	if err != nil {
+		var opErr *todo.BatchOperationError
+		if errors.As(err, &opErr) {
+			opErr.Index = prepared[opErr.Index].index
+		}
+		return nil, "", err
+	}
+
+	var undo []todo.UndoOperation
+	var listIds []int
+	for i, result := range batchResults {
+		operation := prepared[i]
+		if result.Err == nil {
+			listIds = append(listIds, operation.listIds()...)
+			undo = append(undo, operation.undo()...)
+		}
+		results[operation.index] = result
+	}
+	s.cache.ItemsChanged(listIds...)
+
+	if len(undo) == 0 {
+		return results, "", nil
+	}
+
+	return results, registerUndo(s.undoRepo, userId, undo...), nil
+}
+
+func (o batchOperation) listIds() []int {
+	switch o.op.Op {
+	case todo.ItemOpCreate:
+		return []int{o.op.ListId}
+	case todo.ItemOpMove:
+		return []int{o.listId, o.op.ListId}
+	default:
+		return []int{o.listId}
+	}
+}
+
+func (s *TodoItemService) prepareBatchOperation(userId int, op todo.ItemOperation) (batchOperation, error) {
+	operation := batchOperation{op: op}
+	if err := op.Validate(); err != nil {
+		return operation, err
+	}
+
+	if op.Op == todo.ItemOpCreate || op.Op == todo.ItemOpMove {
+		if _, err := s.listRepo.GetById(userId, op.ListId); err != nil {
+			return operation, err
+		}
+	}
+
+	if op.Op == todo.ItemOpCreate {
+		return operation, nil
+	}
+
+	item, err := s.repo.GetById(userId, op.Id)
+	if err != nil {
+		return operation, err
+	}
+
+	if err := checkVersion("item", item.Version, op.Version); err != nil {
+		return operation, err
+	}
+
+	listId, err := s.repo.GetListId(op.Id)
+	if err != nil {
+		return operation, err
+	}
+
+	if op.Op == todo.ItemOpUpdate {
+		changes := *op.Changes
+		changes.Version = op.Version
+		operation.op.Changes = &changes
+	}
+	operation.item = item
+	operation.listId = listId
+
+	return operation, nil
+}
+
+// undo возвращает снимки выполненной операции для отмены.
+func (o batchOperation) undo() []todo.UndoOperation {
+	switch o.op.Op {
+	case todo.ItemOpUpdate:
+		return []todo.UndoOperation{{Entity: todo.UndoEntityItem, Item: &o.item, Version: undoVersion(o.item.Version, false)}}
+	case todo.ItemOpDelete:
+		return []todo.UndoOperation{{Entity: todo.UndoEntityItem, Restore: true, Item: &o.item, ListId: o.listId,
+			Version: undoVersion(o.item.Version, true)}}
+	}
+
+	return nil
 }
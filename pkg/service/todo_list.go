@@ -6,33 +6,120 @@ import (
 )
 
 type TodoListService struct {
-	repo repository.TodoList
+	repo     repository.TodoList
+	undoRepo repository.Undo
+	cache    *CacheInvalidator
 }
 
-func NewTodoListService(repo repository.TodoList) *TodoListService {
-	return &TodoListService{repo: repo}
+func NewTodoListService(repo repository.TodoList, undoRepo repository.Undo, cache *CacheInvalidator) *TodoListService {
+	return &TodoListService{repo: repo, undoRepo: undoRepo, cache: cache}
 }
 
 func (s *TodoListService) Create(userId int, list todo.TodoList) (int, error) {
-	return s.repo.Create(userId, list)
+	id, err := s.repo.Create(userId, list)
+	if err != nil {
+		return 0, err
+	}
+	s.cache.Lists(userId)
+
+	return id, nil
 }
 
+func (s *TodoListService) GetAll(userId int, page todo.PageInput) ([]todo.TodoList, string, error) {
+	if err := page.Validate(); err != nil {
+		return nil, "", err
+	}
 
-func (s *TodoListService) GetAll(userId int) ([]todo.TodoList, error) {
-	return s.repo.GetAll(userId)
+	return s.repo.GetAll(userId, page)
 }
 
 func (s *TodoListService) GetById(userId, listId int) (todo.TodoList, error) {
 	return s.repo.GetById(userId, listId)
 }
 
-func (s *TodoListService) Delete(userId, listId int) error {
-	return s.repo.Delete(userId, listId)
+// Delete переносит запись в корзину. version - ожидаемая версия из If-Match,
+// nil - без проверки.
+func (s *TodoListService) Delete(userId, listId int, version *int) (string, error) {
+	list, err := s.repo.GetById(userId, listId)
+	if err != nil {
+		return "", err
+	}
+
+	userIds, err := s.repo.GetUserIds(listId)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.repo.Delete(userId, listId, version); err != nil {
+		return "", err
+	}
+	s.cache.ListChanged(listId)
+
+	return registerUndo(s.undoRepo, userId, todo.UndoOperation{
+		Entity:  todo.UndoEntityList,
+		Restore: true,
+		List:    &list,
+		UserIds: userIds,
+		Version: undoVersion(list.Version, true),
+	}), nil
 }
 
-func (s *TodoListService) Update(userId, listId int, input todo.UpdateListInput) error {
+func (s *TodoListService) Update(userId, listId int, input todo.UpdateListInput) (string, error) {
 	if err := input.Validate(); err != nil {
-		return err
+		return "", err
+	}
+
+	list, err := s.repo.GetById(userId, listId)
+	if err != nil {
+		return "", err
 	}
-	return s.repo.Update(userId, listId, input)
+
+	if err := s.repo.Update(userId, listId, input); err != nil {
+		return "", err
+	}
+	s.cache.ListChanged(listId)
+
+	return registerUndo(s.undoRepo, userId, todo.UndoOperation{
+		Entity:  todo.UndoEntityList,
+		List:    &list,
+		Version: undoVersion(list.Version, false),
+	}), nil
+}
+
+// Patch применяет к списку JSON Merge Patch или JSON Patch. Патч, который ничего
+// не меняет, не записывается и не создает токен отмены. version - ожидаемая
+// версия из If-Match; без нее запись проверяется по прочитанной версии.
+func (s *TodoListService) Patch(userId, listId int, contentType string, body []byte, version *int) (string, error) {
+	list, err := s.repo.GetById(userId, listId)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkVersion("list", list.Version, version); err != nil {
+		return "", err
+	}
+
+	changes, err := patchChanges(list, contentType, body)
+	if err != nil || len(changes) == 0 {
+		return "", err
+	}
+
+	input, err := listPatchInput(changes)
+	if err != nil {
+		return "", err
+	}
+	// патч вычислен по прочитанной версии, поэтому и без If-Match запись
+	// не должна затереть изменение, сделанное после чтения
+	if version == nil {
+		version = &list.Version
+	}
+	input.Version = version
+
+	return s.Update(userId, listId, input)
+}
+
+// GetUserIds возвращает участников списка без проверки доступа - только для
+// внутренних нужд вроде сброса кэша у всех участников.
+func (s *TodoListService) GetUserIds(listId int) ([]int, error) {
+	return s.repo.GetUserIds(listId)
 }
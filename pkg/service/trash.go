@@ -0,0 +1,39 @@
+package service
+
+import (
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+)
+
+type TrashService struct {
+	repo  repository.Trash
+	cache *CacheInvalidator
+}
+
+func NewTrashService(repo repository.Trash, cache *CacheInvalidator) *TrashService {
+	return &TrashService{repo: repo, cache: cache}
+}
+
+func (s *TrashService) GetAll(userId int) (todo.Trash, error) {
+	return s.repo.GetAll(userId)
+}
+
+func (s *TrashService) Restore(userId int, entityType string, id int) error {
+	switch entityType {
+	case todo.TrashTypeList:
+		if err := s.repo.RestoreList(userId, id); err != nil {
+			return err
+		}
+		s.cache.ListChanged(id)
+	case todo.TrashTypeItem:
+		listId, err := s.repo.RestoreItem(userId, id)
+		if err != nil {
+			return err
+		}
+		s.cache.ItemsChanged(listId)
+	default:
+		return todo.NewError(todo.ErrValidation, "unknown trash entity type")
+	}
+
+	return nil
+}
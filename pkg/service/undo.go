@@ -0,0 +1,64 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const undoTTL = 5 * time.Minute
+
+type UndoService struct {
+	repo  repository.Undo
+	cache *CacheInvalidator
+}
+
+func NewUndoService(repo repository.Undo, cache *CacheInvalidator) *UndoService {
+	return &UndoService{repo: repo, cache: cache}
+}
+
+func (s *UndoService) Undo(userId int, token string) error {
+	listIds, itemIds, err := s.repo.Apply(userId, token)
+	if err != nil {
+		return err
+	}
+
+	for _, listId := range listIds {
+		s.cache.ListChanged(listId)
+	}
+	s.cache.ItemChanged(itemIds...)
+
+	return nil
+}
+
+// undoVersion возвращает версию записи сразу после изменения: обновление
+// увеличивает ее на единицу, перенос в корзину не меняет.
+func undoVersion(version int, restore bool) *int {
+	if !restore {
+		version++
+	}
+
+	return &version
+}
+
+// registerUndo сохраняет обратные операции и возвращает токен отмены. Изменение
+// уже применено, поэтому при ошибке токен просто не выдается.
+func registerUndo(repo repository.Undo, userId int, ops ...todo.UndoOperation) string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		logrus.Errorf("failed to generate undo token: %s", err.Error())
+		return ""
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := repo.Create(userId, token, ops, time.Now().Add(undoTTL)); err != nil {
+		logrus.Errorf("failed to save undo token: %s", err.Error())
+		return ""
+	}
+
+	return token
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+
+	todo "github.com/balamuteon/todo_restapi"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 100
+)
+
+type WebhookService struct {
+	repo repository.Webhook
+}
+
+func NewWebhookService(repo repository.Webhook) *WebhookService {
+	return &WebhookService{repo: repo}
+}
+
+// Create регистрирует вебхук и возвращает его секрет для проверки подписи.
+// Секрет генерируется, если не передан, и больше не отдается.
+func (s *WebhookService) Create(userId int, webhook todo.Webhook) (int, string, error) {
+	if err := webhook.Validate(); err != nil {
+		return 0, "", err
+	}
+
+	if webhook.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return 0, "", err
+		}
+		webhook.Secret = secret
+	}
+
+	id, err := s.repo.Create(userId, webhook)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return id, webhook.Secret, nil
+}
+
+func (s *WebhookService) GetAll(userId int) ([]todo.Webhook, error) {
+	return s.repo.GetAll(userId)
+}
+
+func (s *WebhookService) GetById(userId, webhookId int) (todo.Webhook, error) {
+	return s.repo.GetById(userId, webhookId)
+}
+
+func (s *WebhookService) Update(userId, webhookId int, input todo.UpdateWebhookInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	return s.repo.Update(userId, webhookId, input)
+}
+
+func (s *WebhookService) Delete(userId, webhookId int) error {
+	return s.repo.Delete(userId, webhookId)
+}
+
+// GetDeliveries возвращает журнал доставок вебхука, новые первыми. limit 0 -
+// значение по умолчанию.
+func (s *WebhookService) GetDeliveries(userId, webhookId, limit int) ([]todo.WebhookDelivery, error) {
+	switch {
+	case limit == 0:
+		limit = defaultDeliveriesLimit
+	case limit < 0 || limit > maxDeliveriesLimit:
+		return nil, todo.NewError(todo.ErrValidation, "limit must be between 1 and %d", maxDeliveriesLimit)
+	}
+
+	if _, err := s.repo.GetById(userId, webhookId); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetDeliveries(webhookId, limit)
+}
+
+// Publish ставит событие в очередь вебхуков получателей. Отправкой занимается
+// worker.WebhookDispatcher.
+func (s *WebhookService) Publish(ctx context.Context, userIds []int, event todo.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Enqueue(userIds, event.Type, payload)
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Object - содержимое блоба с поддержкой Seek, что нужно для отдачи Range-запросов.
+type Object interface {
+	io.ReadSeekCloser
+}
+
+// BlobStore хранит бинарное содержимое вложений по ключу.
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (Object, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 - минимальная замена S3 в памяти: PUT, HEAD, GET с Range и DELETE.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") ||
+		r.Header.Get("X-Amz-Date") == "" || r.Header.Get("X-Amz-Content-Sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, ok := f.objects[r.URL.Path]
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodHead, http.MethodGet:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var offset int
+		if rng := r.Header.Get("Range"); rng != "" {
+			offset, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)-offset))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		}
+		if r.Method == http.MethodGet {
+			w.Write(data[offset:])
+		}
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func testBlobStore(t *testing.T, store BlobStore) {
+	ctx := context.Background()
+	content := "hello, attachment"
+
+	err := store.Put(ctx, "blob1", strings.NewReader(content), int64(len(content)), "text/plain")
+	assert.NoError(t, err, "expected no error on put")
+
+	obj, err := store.Open(ctx, "blob1")
+	assert.NoError(t, err, "expected no error on open")
+
+	size, err := obj.Seek(0, io.SeekEnd)
+	assert.NoError(t, err, "expected no error on seek")
+	assert.Equal(t, int64(len(content)), size, "expected object size")
+
+	_, err = obj.Seek(7, io.SeekStart)
+	assert.NoError(t, err, "expected no error on seek")
+	tail, err := io.ReadAll(obj)
+	assert.NoError(t, err, "expected no error on read")
+	assert.Equal(t, content[7:], string(tail), "expected content from offset")
+	assert.NoError(t, obj.Close(), "expected no error on close")
+
+	assert.NoError(t, store.Delete(ctx, "blob1"), "expected no error on delete")
+	_, err = store.Open(ctx, "blob1")
+	assert.ErrorIs(t, err, ErrNotFound, "expected blob to be deleted")
+	assert.NoError(t, store.Delete(ctx, "blob1"), "expected delete to be idempotent")
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	assert.NoError(t, err, "expected no error")
+
+	testBlobStore(t, store)
+
+	err = store.Put(context.Background(), "../escape", strings.NewReader("x"), 1, "text/plain")
+	assert.Error(t, err, "expected error for invalid key")
+}
+
+func TestS3Store(t *testing.T) {
+	srv := httptest.NewServer(&fakeS3{objects: make(map[string][]byte)})
+	defer srv.Close()
+
+	store := NewS3Store(S3Config{
+		Endpoint:  srv.URL,
+		Region:    "us-east-1",
+		Bucket:    "attachments",
+		AccessKey: "access",
+		SecretKey: "secret",
+	}, srv.Client())
+
+	testBlobStore(t, store)
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/balamuteon/todo_restapi/pkg/notify"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type EmailConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// EmailSender периодически забирает письма из email_outbox и отправляет их.
+type EmailSender struct {
+	repo   repository.EmailOutbox
+	mailer *notify.SMTPMailer
+	cfg    EmailConfig
+}
+
+func NewEmailSender(repo repository.EmailOutbox, mailer *notify.SMTPMailer, cfg EmailConfig) *EmailSender {
+	return &EmailSender{repo: repo, mailer: mailer, cfg: cfg}
+}
+
+func (s *EmailSender) Run(ctx context.Context) {
+	runEvery(ctx, s.cfg.Interval, s.tick)
+}
+
+func (s *EmailSender) tick(ctx context.Context) {
+	emails, err := s.repo.ClaimDue(s.cfg.BatchSize, s.cfg.Lease)
+	if err != nil {
+		logrus.Errorf("failed to claim emails: %s", err.Error())
+		return
+	}
+
+	for _, email := range emails {
+		err := s.mailer.Send(ctx, email)
+		switch {
+		case err == nil:
+			err = s.repo.MarkSent(email.Id)
+		case email.Attempts >= s.cfg.MaxAttempts:
+			logrus.Warnf("email %d failed after %d attempts: %s", email.Id, email.Attempts, err.Error())
+			err = s.repo.MarkFailed(email.Id, err.Error())
+		default:
+			nextAttemptAt := time.Now().Add(backoff(email.Attempts, s.cfg.Backoff, s.cfg.MaxBackoff))
+			err = s.repo.MarkRetry(email.Id, nextAttemptAt, err.Error())
+		}
+
+		if err != nil {
+			logrus.Errorf("failed to update email %d: %s", email.Id, err.Error())
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/balamuteon/todo_restapi/pkg/events"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type OutboxConfig struct {
+	Interval   time.Duration
+	BatchSize  int
+	Lease      time.Duration
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// OutboxSink - получатель событий outbox. Name сохраняется в outbox вместе с
+// событием, поэтому его нельзя менять между запусками.
+type OutboxSink struct {
+	Name      string
+	Publisher events.Publisher
+}
+
+// OutboxRelay публикует события из outbox во все sinks. Событие отмечается
+// опубликованным, только когда его приняли все sinks; при повторе оно уходит
+// лишь тем, кто еще не принял. Доставка хотя бы один раз: если отметка не
+// записалась, событие придет повторно, и получатели должны быть к этому готовы.
+type OutboxRelay struct {
+	repo  repository.Outbox
+	sinks []OutboxSink
+	cfg   OutboxConfig
+}
+
+func NewOutboxRelay(repo repository.Outbox, cfg OutboxConfig, sinks ...OutboxSink) *OutboxRelay {
+	return &OutboxRelay{repo: repo, sinks: sinks, cfg: cfg}
+}
+
+func (r *OutboxRelay) Run(ctx context.Context) {
+	runEvery(ctx, r.cfg.Interval, r.tick)
+}
+
+func (r *OutboxRelay) tick(ctx context.Context) {
+	outboxEvents, err := r.repo.Claim(r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		logrus.Errorf("failed to claim outbox events: %s", err.Error())
+		return
+	}
+
+	for _, outboxEvent := range outboxEvents {
+		published := append([]string(nil), outboxEvent.PublishedSinks...)
+		var errs []error
+		for _, sink := range r.sinks {
+			if outboxEvent.PublishedTo(sink.Name) {
+				continue
+			}
+
+			if err := sink.Publisher.Publish(ctx, outboxEvent.Recipients(), outboxEvent.Event()); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", sink.Name, err))
+				continue
+			}
+			published = append(published, sink.Name)
+		}
+
+		// событие не отбрасывается после неудач, а ждет, пока sink поднимется
+		if err := errors.Join(errs...); err != nil {
+			logrus.Warnf("outbox event %d failed on attempt %d: %s", outboxEvent.Id, outboxEvent.Attempts, err.Error())
+			nextAttemptAt := time.Now().Add(backoff(outboxEvent.Attempts, r.cfg.Backoff, r.cfg.MaxBackoff))
+			err = r.repo.MarkRetry(outboxEvent.Id, nextAttemptAt, err.Error(), published)
+		} else {
+			err = r.repo.MarkPublished(outboxEvent.Id)
+		}
+
+		if err != nil {
+			logrus.Errorf("failed to update outbox event %d: %s", outboxEvent.Id, err.Error())
+		}
+	}
+}
+
+// OutboxCleaner удаляет опубликованные события старше retention.
+type OutboxCleaner struct {
+	repo      repository.Outbox
+	interval  time.Duration
+	retention time.Duration
+}
+
+func NewOutboxCleaner(repo repository.Outbox, interval, retention time.Duration) *OutboxCleaner {
+	return &OutboxCleaner{repo: repo, interval: interval, retention: retention}
+}
+
+func (c *OutboxCleaner) Run(ctx context.Context) {
+	runEvery(ctx, c.interval, c.clean)
+}
+
+func (c *OutboxCleaner) clean(ctx context.Context) {
+	deleted, err := c.repo.DeletePublishedBefore(time.Now().Add(-c.retention))
+	if err != nil {
+		logrus.Errorf("failed to delete published outbox events: %s", err.Error())
+		return
+	}
+
+	if deleted > 0 {
+		logrus.Debugf("deleted %d published outbox events", deleted)
+	}
+}
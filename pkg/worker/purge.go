@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/balamuteon/todo_restapi/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// TrashPurger окончательно удаляет содержимое корзины старше retention.
+type TrashPurger struct {
+	repo      repository.Trash
+	store     storage.BlobStore
+	interval  time.Duration
+	retention time.Duration
+}
+
+func NewTrashPurger(repo repository.Trash, store storage.BlobStore, interval, retention time.Duration) *TrashPurger {
+	return &TrashPurger{repo: repo, store: store, interval: interval, retention: retention}
+}
+
+func (p *TrashPurger) Run(ctx context.Context) {
+	runEvery(ctx, p.interval, p.purge)
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	keys, err := p.repo.Purge(time.Now().Add(-p.retention))
+	if err != nil {
+		logrus.Errorf("failed to purge trash: %s", err.Error())
+		return
+	}
+
+	for _, key := range keys {
+		if err := p.store.Delete(ctx, key); err != nil {
+			logrus.Errorf("failed to delete blob %s: %s", key, err.Error())
+		}
+	}
+}
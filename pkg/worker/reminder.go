@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/balamuteon/todo_restapi/pkg/notify"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type ReminderConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// ReminderScheduler периодически забирает сработавшие напоминания и доставляет их.
+type ReminderScheduler struct {
+	repo     repository.Reminder
+	notifier notify.Notifier
+	cfg      ReminderConfig
+}
+
+func NewReminderScheduler(repo repository.Reminder, notifier notify.Notifier, cfg ReminderConfig) *ReminderScheduler {
+	return &ReminderScheduler{repo: repo, notifier: notifier, cfg: cfg}
+}
+
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	runEvery(ctx, s.cfg.Interval, s.tick)
+}
+
+func (s *ReminderScheduler) tick(ctx context.Context) {
+	reminders, err := s.repo.ClaimDue(s.cfg.BatchSize, s.cfg.Lease)
+	if err != nil {
+		logrus.Errorf("failed to claim due reminders: %s", err.Error())
+		return
+	}
+
+	for _, reminder := range reminders {
+		err := s.notifier.Notify(ctx, reminder)
+		switch {
+		case err == nil:
+			err = s.repo.MarkSent(reminder.Id)
+		case reminder.Attempts >= s.cfg.MaxAttempts:
+			logrus.Warnf("reminder %d failed after %d attempts: %s", reminder.Id, reminder.Attempts, err.Error())
+			err = s.repo.MarkFailed(reminder.Id, err.Error())
+		default:
+			nextAttemptAt := time.Now().Add(backoff(reminder.Attempts, s.cfg.Backoff, s.cfg.MaxBackoff))
+			err = s.repo.MarkRetry(reminder.Id, nextAttemptAt, err.Error())
+		}
+
+		if err != nil {
+			logrus.Errorf("failed to update reminder %d: %s", reminder.Id, err.Error())
+		}
+	}
+}
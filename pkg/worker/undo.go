@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// UndoCleaner удаляет просроченные токены отмены. Просроченный токен и так
+// нельзя применить, очистка только не дает таблице расти.
+type UndoCleaner struct {
+	repo     repository.Undo
+	interval time.Duration
+}
+
+func NewUndoCleaner(repo repository.Undo, interval time.Duration) *UndoCleaner {
+	return &UndoCleaner{repo: repo, interval: interval}
+}
+
+func (c *UndoCleaner) Run(ctx context.Context) {
+	runEvery(ctx, c.interval, c.clean)
+}
+
+func (c *UndoCleaner) clean(ctx context.Context) {
+	deleted, err := c.repo.DeleteExpired()
+	if err != nil {
+		logrus.Errorf("failed to delete expired undo tokens: %s", err.Error())
+		return
+	}
+
+	if deleted > 0 {
+		logrus.Debugf("deleted %d expired undo tokens", deleted)
+	}
+}
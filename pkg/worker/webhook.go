@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/balamuteon/todo_restapi/pkg/notify"
+	"github.com/balamuteon/todo_restapi/pkg/repository"
+	"github.com/sirupsen/logrus"
+)
+
+type WebhookConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	// DisableAfter - после скольких неудачных попыток подряд вебхук отключается
+	DisableAfter int
+}
+
+// WebhookDispatcher периодически забирает доставки из очереди и отправляет их
+// подписчикам, повторяя неудачные с экспоненциальной задержкой.
+type WebhookDispatcher struct {
+	repo   repository.Webhook
+	sender *notify.WebhookSender
+	cfg    WebhookConfig
+}
+
+func NewWebhookDispatcher(repo repository.Webhook, sender *notify.WebhookSender, cfg WebhookConfig) *WebhookDispatcher {
+	return &WebhookDispatcher{repo: repo, sender: sender, cfg: cfg}
+}
+
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	runEvery(ctx, d.cfg.Interval, d.tick)
+}
+
+func (d *WebhookDispatcher) tick(ctx context.Context) {
+	deliveries, err := d.repo.ClaimDue(d.cfg.BatchSize, d.cfg.Lease)
+	if err != nil {
+		logrus.Errorf("failed to claim webhook deliveries: %s", err.Error())
+		return
+	}
+
+	for _, delivery := range deliveries {
+		status, err := d.sender.Send(ctx, delivery)
+		if err == nil {
+			if err := d.repo.MarkDelivered(delivery.Id, status); err != nil {
+				logrus.Errorf("failed to update webhook delivery %d: %s", delivery.Id, err.Error())
+			}
+			continue
+		}
+
+		var responseStatus *int
+		if status != 0 {
+			responseStatus = &status
+		}
+
+		if delivery.Attempts >= d.cfg.MaxAttempts {
+			logrus.Warnf("webhook delivery %d failed after %d attempts: %s", delivery.Id, delivery.Attempts, err.Error())
+			err = d.repo.MarkFailed(delivery.Id, responseStatus, err.Error())
+		} else {
+			nextAttemptAt := time.Now().Add(backoff(delivery.Attempts, d.cfg.Backoff, d.cfg.MaxBackoff))
+			err = d.repo.MarkRetry(delivery.Id, nextAttemptAt, responseStatus, err.Error())
+		}
+		if err != nil {
+			logrus.Errorf("failed to update webhook delivery %d: %s", delivery.Id, err.Error())
+		}
+
+		disabled, err := d.repo.RecordFailure(delivery.WebhookId, d.cfg.DisableAfter)
+		if err != nil {
+			logrus.Errorf("failed to record webhook %d failure: %s", delivery.WebhookId, err.Error())
+		} else if disabled {
+			logrus.Warnf("webhook %d disabled after %d failed attempts in a row", delivery.WebhookId, d.cfg.DisableAfter)
+		}
+	}
+}
+
+// WebhookLogCleaner удаляет старые записи журнала доставок.
+type WebhookLogCleaner struct {
+	repo      repository.Webhook
+	interval  time.Duration
+	retention time.Duration
+}
+
+func NewWebhookLogCleaner(repo repository.Webhook, interval, retention time.Duration) *WebhookLogCleaner {
+	return &WebhookLogCleaner{repo: repo, interval: interval, retention: retention}
+}
+
+func (c *WebhookLogCleaner) Run(ctx context.Context) {
+	runEvery(ctx, c.interval, c.clean)
+}
+
+func (c *WebhookLogCleaner) clean(ctx context.Context) {
+	deleted, err := c.repo.DeleteDeliveriesBefore(time.Now().Add(-c.retention))
+	if err != nil {
+		logrus.Errorf("failed to delete old webhook deliveries: %s", err.Error())
+		return
+	}
+
+	if deleted > 0 {
+		logrus.Debugf("deleted %d old webhook deliveries", deleted)
+	}
+}
@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// runEvery вызывает fn сразу и затем с интервалом interval, пока ctx не отменен.
+func runEvery(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backoff возвращает экспоненциальную задержку перед попыткой attempt (начиная с 1).
+func backoff(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDelay {
+		return maxDelay
+	}
+
+	return delay
+}
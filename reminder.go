@@ -0,0 +1,77 @@
+package todo
+
+import (
+	"net/mail"
+	"time"
+)
+
+const (
+	ReminderChannelWebhook = "webhook"
+	ReminderChannelEmail   = "email"
+	ReminderChannelLog     = "log"
+)
+
+// Статусы напоминаний; письма в email_outbox проходят те же статусы.
+const (
+	ReminderStatusPending = "pending"
+	ReminderStatusSent    = "sent"
+	ReminderStatusFailed  = "failed"
+)
+
+type Reminder struct {
+	Id            int        `json:"id" db:"id"`
+	ItemId        int        `json:"item_id" db:"item_id"`
+	UserId        int        `json:"-" db:"user_id"`
+	RemindAt      *time.Time `json:"remind_at,omitempty" db:"remind_at"`
+	OffsetSeconds *int       `json:"offset_seconds,omitempty" db:"offset_seconds"` // before item due_at
+	Channel       string     `json:"channel" db:"channel" binding:"required"`
+	Target        string     `json:"target" db:"target"`
+	Status        string     `json:"status" db:"status"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     *string    `json:"last_error,omitempty" db:"last_error"`
+	SentAt        *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+}
+
+func (r Reminder) Validate() error {
+	if (r.RemindAt == nil) == (r.OffsetSeconds == nil) {
+		return NewError(ErrValidation, "exactly one of remind_at or offset_seconds must be set")
+	}
+
+	if r.OffsetSeconds != nil && *r.OffsetSeconds < 0 {
+		return NewError(ErrValidation, "offset_seconds must not be negative")
+	}
+
+	switch r.Channel {
+	case ReminderChannelWebhook:
+		if r.Target == "" {
+			return NewError(ErrValidation, "target is required for %s channel", r.Channel)
+		}
+		return validateWebhookURL(r.Target)
+	case ReminderChannelEmail:
+		if _, err := mail.ParseAddress(r.Target); err != nil {
+			return NewError(ErrValidation, "target must be an email address for %s channel", r.Channel)
+		}
+	case ReminderChannelLog:
+	default:
+		return NewError(ErrValidation, "unknown reminder channel")
+	}
+
+	return nil
+}
+
+// DueReminder - напоминание, захваченное планировщиком для доставки.
+type DueReminder struct {
+	Reminder
+	ItemTitle string     `json:"item_title" db:"item_title"`
+	ItemDueAt *time.Time `json:"item_due_at,omitempty" db:"item_due_at"`
+	FireAt    time.Time  `json:"fire_at" db:"fire_at"`
+}
+
+// Email - письмо из очереди email_outbox, захваченное отправителем.
+type Email struct {
+	Id        int    `db:"id"`
+	Recipient string `db:"recipient"`
+	Subject   string `db:"subject"`
+	Body      string `db:"body"`
+	Attempts  int    `db:"attempts"`
+}
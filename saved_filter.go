@@ -0,0 +1,50 @@
+package todo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SavedFilter - пользовательский умный список: сохраненный запрос на языке
+// фильтров задач.
+type SavedFilter struct {
+	Id    int    `json:"id" db:"id"`
+	Name  string `json:"name" db:"name" binding:"required"`
+	Query string `json:"query" db:"query" binding:"required"`
+}
+
+type UpdateSavedFilterInput struct {
+	Name  *string `json:"name"`
+	Query *string `json:"query"`
+}
+
+func (i UpdateSavedFilterInput) Validate() error {
+	if i.Name == nil && i.Query == nil {
+		return NewError(ErrValidation, "update structure has no values")
+	}
+
+	if i.Name != nil && strings.TrimSpace(*i.Name) == "" {
+		return NewError(ErrValidation, "filter name is empty")
+	}
+
+	return nil
+}
+
+// SmartList - виртуальный список, содержимое которого вычисляется при чтении.
+// Id встроенных списков - их ключ (today), сохраненных фильтров - id фильтра.
+type SmartList struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Query   string `json:"query"`
+	BuiltIn bool   `json:"built_in"`
+}
+
+var BuiltInSmartLists = []SmartList{
+	{Id: "today", Name: "Today", Query: "due:today done:false", BuiltIn: true},
+	{Id: "overdue", Name: "Overdue", Query: "due<now done:false", BuiltIn: true},
+	{Id: "assigned", Name: "Assigned to me", Query: "assignee:me done:false", BuiltIn: true},
+}
+
+func (f SavedFilter) SmartList() SmartList {
+	return SmartList{Id: strconv.Itoa(f.Id), Name: f.Name, Query: f.Query}
+}
@@ -0,0 +1,5 @@
+DROP TABLE email_outbox;
+
+DROP TABLE reminders;
+
+ALTER TABLE todo_items DROP COLUMN due_at;
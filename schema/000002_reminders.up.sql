@@ -0,0 +1,29 @@
+ALTER TABLE todo_items ADD COLUMN due_at timestamptz;
+
+CREATE TABLE reminders (
+	id serial NOT NULL UNIQUE,
+	item_id int REFERENCES todo_items(id) ON DELETE CASCADE NOT NULL,
+	user_id int REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+	remind_at timestamptz,
+	offset_seconds int CHECK (offset_seconds >= 0),
+	channel varchar(32) NOT NULL,
+	target varchar(1024) NOT NULL DEFAULT '',
+	status varchar(16) NOT NULL DEFAULT 'pending',
+	attempts int NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz NOT NULL DEFAULT now(),
+	last_error text,
+	sent_at timestamptz,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	CHECK ((remind_at IS NULL) <> (offset_seconds IS NULL))
+);
+
+CREATE INDEX reminders_pending_idx ON reminders (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE email_outbox (
+	id serial NOT NULL UNIQUE,
+	recipient varchar(255) NOT NULL,
+	subject varchar(255) NOT NULL,
+	body text NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	sent_at timestamptz
+);
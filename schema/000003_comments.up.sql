@@ -0,0 +1,11 @@
+CREATE TABLE comments (
+	id serial NOT NULL UNIQUE,
+	item_id int REFERENCES todo_items(id) ON DELETE CASCADE NOT NULL,
+	author_id int REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+	body text NOT NULL,
+	edited boolean NOT NULL DEFAULT false,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX comments_item_id_idx ON comments (item_id, created_at);
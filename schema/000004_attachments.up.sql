@@ -0,0 +1,13 @@
+CREATE TABLE attachments (
+	id serial NOT NULL UNIQUE,
+	item_id int REFERENCES todo_items(id) ON DELETE CASCADE NOT NULL,
+	uploader_id int REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+	filename varchar(255) NOT NULL,
+	content_type varchar(255) NOT NULL,
+	size bigint NOT NULL,
+	checksum char(64) NOT NULL,
+	storage_key varchar(255) NOT NULL UNIQUE,
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX attachments_item_id_idx ON attachments (item_id);
@@ -0,0 +1,5 @@
+DROP TRIGGER users_lists_unassign_removed_member ON users_lists;
+
+DROP FUNCTION unassign_removed_member();
+
+ALTER TABLE todo_items DROP COLUMN assignee_id;
@@ -0,0 +1,17 @@
+ALTER TABLE todo_items ADD COLUMN assignee_id int REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX todo_items_assignee_id_idx ON todo_items (assignee_id);
+
+-- Участник, удаленный из списка, перестает быть исполнителем задач этого списка
+CREATE FUNCTION unassign_removed_member() RETURNS trigger AS $$
+BEGIN
+	UPDATE todo_items SET assignee_id = NULL
+	WHERE assignee_id = OLD.user_id
+		AND id IN (SELECT item_id FROM lists_items WHERE list_id = OLD.list_id);
+	RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_lists_unassign_removed_member
+	AFTER DELETE ON users_lists
+	FOR EACH ROW EXECUTE FUNCTION unassign_removed_member();
@@ -0,0 +1,3 @@
+ALTER TABLE todo_items DROP COLUMN deleted_at;
+
+ALTER TABLE todo_lists DROP COLUMN deleted_at;
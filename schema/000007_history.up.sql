@@ -0,0 +1,11 @@
+CREATE TABLE history (
+	id serial NOT NULL UNIQUE,
+	entity_type varchar(16) NOT NULL,
+	entity_id int NOT NULL,
+	actor_id int REFERENCES users(id) ON DELETE SET NULL,
+	action varchar(16) NOT NULL,
+	changes jsonb NOT NULL DEFAULT '{}',
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX history_entity_idx ON history (entity_type, entity_id, id DESC);
@@ -0,0 +1,8 @@
+CREATE TABLE undo_tokens (
+	token varchar(64) NOT NULL PRIMARY KEY,
+	user_id int REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+	operations jsonb NOT NULL,
+	expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX undo_tokens_expires_at_idx ON undo_tokens (expires_at);
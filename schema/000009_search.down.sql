@@ -0,0 +1,3 @@
+ALTER TABLE todo_items DROP COLUMN search_vector;
+
+ALTER TABLE todo_lists DROP COLUMN search_vector;
@@ -0,0 +1,9 @@
+CREATE TABLE saved_filters (
+	id serial NOT NULL UNIQUE,
+	user_id int REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+	name varchar(255) NOT NULL,
+	query text NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX saved_filters_user_id_idx ON saved_filters (user_id, id);
@@ -0,0 +1,30 @@
+CREATE TABLE webhooks (
+	id serial NOT NULL UNIQUE,
+	user_id int REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+	url varchar(1024) NOT NULL,
+	secret varchar(255) NOT NULL,
+	events text[] NOT NULL DEFAULT '{}',
+	active boolean NOT NULL DEFAULT true,
+	failure_count int NOT NULL DEFAULT 0,
+	disabled_at timestamptz,
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX webhooks_user_id_idx ON webhooks (user_id) WHERE active;
+
+CREATE TABLE webhook_deliveries (
+	id serial NOT NULL UNIQUE,
+	webhook_id int REFERENCES webhooks(id) ON DELETE CASCADE NOT NULL,
+	event_type varchar(64) NOT NULL,
+	payload jsonb NOT NULL,
+	status varchar(16) NOT NULL DEFAULT 'pending',
+	attempts int NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz NOT NULL DEFAULT now(),
+	response_status int,
+	last_error text,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	delivered_at timestamptz
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, id);
@@ -0,0 +1,15 @@
+CREATE TABLE outbox (
+	id bigserial NOT NULL UNIQUE,
+	event_type varchar(64) NOT NULL,
+	list_id int NOT NULL,
+	item_id int,
+	actor_id int NOT NULL,
+	user_ids int[] NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	attempts int NOT NULL DEFAULT 0,
+	next_attempt_at timestamptz NOT NULL DEFAULT now(),
+	last_error text,
+	published_at timestamptz
+);
+
+CREATE INDEX outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
@@ -0,0 +1,7 @@
+DROP INDEX email_outbox_pending_idx;
+
+ALTER TABLE email_outbox
+	DROP COLUMN last_error,
+	DROP COLUMN next_attempt_at,
+	DROP COLUMN attempts,
+	DROP COLUMN status;
@@ -0,0 +1,7 @@
+ALTER TABLE email_outbox
+	ADD COLUMN status varchar(16) NOT NULL DEFAULT 'pending',
+	ADD COLUMN attempts int NOT NULL DEFAULT 0,
+	ADD COLUMN next_attempt_at timestamptz NOT NULL DEFAULT now(),
+	ADD COLUMN last_error text;
+
+CREATE INDEX email_outbox_pending_idx ON email_outbox (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+DROP INDEX todo_items_tags_idx;
+
+ALTER TABLE todo_items
+	DROP COLUMN tags,
+	DROP COLUMN priority;
@@ -0,0 +1,5 @@
+ALTER TABLE todo_items
+	ADD COLUMN priority smallint NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 3),
+	ADD COLUMN tags text[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX todo_items_tags_idx ON todo_items USING gin (tags);
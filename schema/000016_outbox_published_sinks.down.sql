@@ -0,0 +1 @@
+ALTER TABLE outbox DROP COLUMN published_sinks;
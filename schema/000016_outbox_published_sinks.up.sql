@@ -0,0 +1 @@
+ALTER TABLE outbox ADD COLUMN published_sinks text[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,56 @@
+package todo
+
+import (
+	"strings"
+)
+
+const (
+	SearchTypeList = "list"
+	SearchTypeItem = "item"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchHit - найденный список или задача. Title и Description - HTML: текст
+// экранирован, совпадения обернуты в <mark>...</mark>.
+type SearchHit struct {
+	Type        string  `json:"type" db:"type"`
+	Id          int     `json:"id" db:"id"`
+	ListId      int     `json:"list_id" db:"list_id"`
+	Title       string  `json:"title" db:"title"`
+	Description string  `json:"description" db:"description"`
+	Rank        float64 `json:"rank" db:"rank"`
+}
+
+type SearchInput struct {
+	Query string `form:"q"`
+	Limit int    `form:"limit"`
+}
+
+func (i SearchInput) Validate() error {
+	if strings.TrimSpace(i.Query) == "" {
+		return NewError(ErrValidation, "q is required")
+	}
+
+	if len(i.Query) > maxFilterQueryLength {
+		return NewError(ErrValidation, "q must be at most %d characters", maxFilterQueryLength)
+	}
+
+	if i.Limit < 0 || i.Limit > MaxSearchLimit {
+		return NewError(ErrValidation, "limit must be between 1 and %d", MaxSearchLimit)
+	}
+
+	return nil
+}
+
+// Size возвращает число результатов с учетом значения по умолчанию.
+func (i SearchInput) Size() int {
+	if i.Limit == 0 {
+		return DefaultSearchLimit
+	}
+
+	return i.Limit
+}
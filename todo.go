@@ -1,11 +1,17 @@
 package todo
 
-import "errors"
+import (
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type TodoList struct {
 	Id          int    `json:"id" db:"id"`
 	Title       string `json:"title" db:"title" binding:"required"`
 	Description string `json:"description" db:"description"`
+	Version     int    `json:"version" db:"version"` // растет с каждым изменением, отдается в ETag
 }
 
 type UsersList struct {
@@ -14,11 +20,81 @@ type UsersList struct {
 	ListId int
 }
 
+// Приоритет задачи: 0 - не задан, дальше по возрастанию важности.
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+const (
+	maxTags      = 20
+	maxTagLength = 32
+)
+
 type TodoItem struct {
-	Id          int    `json:"id" db:"id"`
-	Title       string `json:"title" db:"title" binding:"required"`
-	Description string `json:"description" db:"description"`
-	Done        bool   `json:"done" db:"done"`
+	Id          int            `json:"id" db:"id"`
+	Title       string         `json:"title" db:"title" binding:"required"`
+	Description string         `json:"description" db:"description"`
+	Done        bool           `json:"done" db:"done"`
+	DueAt       *time.Time     `json:"due_at" db:"due_at"`
+	AssigneeId  *int           `json:"assignee_id" db:"assignee_id"`
+	Priority    int            `json:"priority" db:"priority"`
+	Tags        pq.StringArray `json:"tags" db:"tags"`
+	Version     int            `json:"version" db:"version"`
+}
+
+// Validate проверяет поля новой задачи, которые не покрывает binding.
+func (i TodoItem) Validate() error {
+	if err := validatePriority(i.Priority); err != nil {
+		return err
+	}
+
+	return validateTags(i.Tags)
+}
+
+func validatePriority(priority int) error {
+	if priority < PriorityNone || priority > PriorityHigh {
+		return NewError(ErrValidation, "priority must be between %d and %d", PriorityNone, PriorityHigh)
+	}
+
+	return nil
+}
+
+func validateTags(tags []string) error {
+	if len(tags) > maxTags {
+		return NewError(ErrValidation, "item can have at most %d tags", maxTags)
+	}
+
+	for _, tag := range tags {
+		if err := validateTag(tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateTag требует непустой тег без пробелов по краям, чтобы фильтр по
+// точному совпадению находил его.
+func validateTag(tag string) error {
+	if tag == "" || tag != strings.TrimSpace(tag) {
+		return NewError(ErrValidation, "tags must be non-empty and have no surrounding spaces")
+	}
+
+	if len([]rune(tag)) > maxTagLength {
+		return NewError(ErrValidation, "tags must be at most %d characters", maxTagLength)
+	}
+
+	return nil
+}
+
+// ItemWithList - задача вместе со списком, в котором она лежит.
+type ItemWithList struct {
+	TodoItem
+	ListId    int    `json:"list_id" db:"list_id"`
+	ListTitle string `json:"list_title" db:"list_title"`
 }
 
 type ListsItem struct {
@@ -30,26 +106,57 @@ type ListsItem struct {
 type UpdateListInput struct {
 	Title       *string `json:"title"`
 	Description *string `json:"description"`
+	// Version - ожидаемая версия из If-Match; nil - без проверки
+	Version *int `json:"-"`
 }
 
 func (i UpdateListInput) Validate() error {
 	if i.Title == nil && i.Description == nil {
-		return errors.New("update structure has no values")
+		return NewError(ErrValidation, "update structure has no values")
 	}
 
 	return nil
 }
 
 type UpdateItemInput struct {
-	Title       *string `json:"title"`
-	Description *string `json:"description"`
-	Done        *bool   `json:"done"`
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	Done        *bool      `json:"done"`
+	DueAt       *time.Time `json:"due_at"`
+	Priority    *int       `json:"priority"`
+	Tags        *[]string  `json:"tags"` // пустой массив снимает все теги
+	// ClearDueAt снимает срок задачи: в JSON null неотличим от отсутствия
+	// поля, поэтому нужен отдельный флаг. PATCH выставляет его по явному null
+	ClearDueAt bool `json:"clear_due_at"`
+	// Version - ожидаемая версия из If-Match; nil - без проверки
+	Version *int `json:"-"`
 }
 
 func (i UpdateItemInput) Validate() error {
-	if i.Title == nil && i.Description == nil && i.Done == nil {
-		return errors.New("update structure has no values")
+	if i.Title == nil && i.Description == nil && i.Done == nil && i.DueAt == nil && !i.ClearDueAt &&
+		i.Priority == nil && i.Tags == nil {
+		return NewError(ErrValidation, "update structure has no values")
+	}
+
+	if i.DueAt != nil && i.ClearDueAt {
+		return NewError(ErrValidation, "due_at cannot be set and cleared at once")
+	}
+
+	if i.Priority != nil {
+		if err := validatePriority(*i.Priority); err != nil {
+			return err
+		}
+	}
+
+	if i.Tags != nil {
+		if err := validateTags(*i.Tags); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+type AssignItemInput struct {
+	AssigneeId *int `json:"assignee_id"` // null снимает исполнителя
+}
@@ -0,0 +1,24 @@
+package todo
+
+import "time"
+
+const (
+	TrashTypeList = "lists"
+	TrashTypeItem = "items"
+)
+
+type TrashedList struct {
+	TodoList
+	DeletedAt time.Time `json:"deleted_at" db:"deleted_at"`
+}
+
+type TrashedItem struct {
+	TodoItem
+	ListId    int       `json:"list_id" db:"list_id"`
+	DeletedAt time.Time `json:"deleted_at" db:"deleted_at"`
+}
+
+type Trash struct {
+	Lists []TrashedList `json:"lists"`
+	Items []TrashedItem `json:"items"`
+}
@@ -0,0 +1,21 @@
+package todo
+
+const (
+	UndoEntityList = "list"
+	UndoEntityItem = "item"
+)
+
+// UndoOperation - снимок состояния сущности до изменения. Restore означает, что
+// сущность была удалена и при отмене ее нужно вернуть вместе со связями.
+// Version - версия записи сразу после изменения: если с тех пор запись
+// изменили, отмена отклоняется. nil - без проверки (токены, выданные до
+// появления поля).
+type UndoOperation struct {
+	Entity  string    `json:"entity"`
+	Restore bool      `json:"restore"`
+	List    *TodoList `json:"list,omitempty"`
+	UserIds []int     `json:"user_ids,omitempty"` // связи users_lists списка
+	Item    *TodoItem `json:"item,omitempty"`
+	ListId  int       `json:"list_id,omitempty"` // связь lists_items задачи
+	Version *int      `json:"version,omitempty"`
+}
@@ -0,0 +1,117 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookEvents - типы событий, на которые можно подписать вебхук.
+var WebhookEvents = []string{
+	EventListCreated, EventListUpdated, EventListDeleted,
+	EventItemCreated, EventItemUpdated, EventItemCompleted, EventItemDeleted, EventItemMoved,
+}
+
+// Webhook - подписка пользователя на события его списков. Пустой Events
+// означает все события. Secret отдается только при создании.
+type Webhook struct {
+	Id           int            `json:"id" db:"id"`
+	UserId       int            `json:"-" db:"user_id"`
+	URL          string         `json:"url" db:"url" binding:"required"`
+	Secret       string         `json:"secret,omitempty" db:"secret"`
+	Events       pq.StringArray `json:"events" db:"events"`
+	Active       bool           `json:"active" db:"active"`
+	FailureCount int            `json:"failure_count" db:"failure_count"`
+	DisabledAt   *time.Time     `json:"disabled_at,omitempty" db:"disabled_at"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+}
+
+func (w Webhook) Validate() error {
+	if err := validateWebhookURL(w.URL); err != nil {
+		return err
+	}
+
+	return validateWebhookEvents(w.Events)
+}
+
+// UpdateWebhookInput - изменение подписки. Active: true включает вебхук,
+// отключенный после ошибок, и сбрасывает счетчик ошибок.
+type UpdateWebhookInput struct {
+	URL    *string   `json:"url"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
+
+func (i UpdateWebhookInput) Validate() error {
+	if i.URL == nil && i.Events == nil && i.Active == nil {
+		return NewError(ErrValidation, "update structure has no values")
+	}
+
+	if i.URL != nil {
+		if err := validateWebhookURL(*i.URL); err != nil {
+			return err
+		}
+	}
+
+	if i.Events != nil {
+		return validateWebhookEvents(*i.Events)
+	}
+
+	return nil
+}
+
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewError(ErrValidation, "webhook url must be an absolute http or https url")
+	}
+
+	return nil
+}
+
+func validateWebhookEvents(events []string) error {
+	for _, event := range events {
+		known := false
+		for _, webhookEvent := range WebhookEvents {
+			if event == webhookEvent {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return NewError(ErrValidation, "unknown webhook event %q", event)
+		}
+	}
+
+	return nil
+}
+
+// WebhookDelivery - запись журнала доставки одного события одному вебхуку.
+type WebhookDelivery struct {
+	Id             int             `json:"id" db:"id"`
+	WebhookId      int             `json:"webhook_id" db:"webhook_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	ResponseStatus *int            `json:"response_status,omitempty" db:"response_status"`
+	LastError      *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// DueWebhookDelivery - доставка, захваченная обработчиком очереди.
+type DueWebhookDelivery struct {
+	WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}